@@ -0,0 +1,88 @@
+// Package atomicfile writes files so that readers see either the old content or the complete
+// new content, never a partial write.
+package atomicfile
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// TempSuffix ends the name of every in-progress atomic write, so walks and the
+// watcher can recognize and ignore half-written files.
+const TempSuffix = ".aegis-tmp"
+
+// Write creates path with the given permissions by writing through fn into a
+// temporary file in the same directory, syncing it to disk and renaming it into place.
+// An interrupted or failed write leaves any existing file at path untouched and removes
+// the temporary file, so callers may delete the source only after this returns nil. Before the
+// rename, the size on disk is checked against the bytes written, so a write that was silently
+// cut short (a full disk on some filesystems) is reported instead of replacing anything.
+func Write(path string, perm os.FileMode, fn func(w io.Writer) error) (err error) {
+	dir := filepath.Dir(path)
+	// The suffix keeps half-written files from being picked up as .aegis files.
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".*"+TempSuffix)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	w := &sizeWriter{w: tmp}
+	if err = fn(w); err != nil {
+		return err
+	}
+	if err = tmp.Chmod(perm); err != nil {
+		return err
+	}
+	if err = tmp.Sync(); err != nil { // Data must be on disk before the rename makes it visible.
+		return err
+	}
+	stat, err := tmp.Stat()
+	if err != nil {
+		return err
+	}
+	if stat.Size() != w.n {
+		return fmt.Errorf("incomplete write: %d of %d bytes reached the disk", stat.Size(), w.n)
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	if err = os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+	syncDir(dir)
+	return nil
+}
+
+// sizeWriter counts the bytes successfully written through it.
+type sizeWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (s *sizeWriter) Write(p []byte) (int, error) {
+	n, err := s.w.Write(p)
+	s.n += int64(n)
+	return n, err
+}
+
+// IsTemp reports whether name is a temporary file created by Write.
+func IsTemp(name string) bool {
+	return strings.HasSuffix(name, TempSuffix)
+}
+
+// syncDir flushes a directory entry update (such as a rename) to disk. Errors are ignored:
+// some platforms and filesystems do not support syncing directories.
+func syncDir(dir string) {
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+}
@@ -0,0 +1,98 @@
+package cli
+
+import (
+	"aegis/internal/crypto"
+	"crypto/rand"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/crypto/scrypt"
+)
+
+// benchTarget holds --target: the longest acceptable key derivation time.
+var benchTarget time.Duration
+
+// benchKDF holds the r and p parameters held fixed while N is varied.
+var benchKDF = crypto.DefaultKDFParams
+
+// benchMinN is the smallest scrypt N measured.
+const benchMinN = 1 << 10
+
+var benchKDFCmd = &cobra.Command{
+	Use:   "bench-kdf",
+	Short: "Measure key derivation time to choose scrypt cost parameters",
+	Long: `Time scrypt on this machine for increasing values of N (with the given r and p) and recommend
+the highest N whose derivation stays under --target. Seal runs the KDF once per session, and
+unseal once per distinct salt, so this is roughly the delay each command adds.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if benchTarget <= 0 {
+			errorf("Error: --target must be positive\n")
+			os.Exit(exitFatal)
+		}
+		probe := benchKDF
+		probe.N = benchMinN
+		if err := probe.Validate(); err != nil {
+			errorf("Error: %v\n", err)
+			os.Exit(exitFatal)
+		}
+
+		salt := make([]byte, crypto.SaltSize)
+		if _, err := rand.Read(salt); err != nil {
+			errorf("Error: failed to generate salt: %v\n", err)
+			os.Exit(exitFatal)
+		}
+
+		fmt.Printf("%s Timing scrypt (r=%d, p=%d) against a target of %v...\n\n", markTimer, benchKDF.R, benchKDF.P, benchTarget)
+		fmt.Printf("   %-10s %-10s %s\n", "N", "Memory", "Time")
+
+		best := 0
+		for kdf := probe; kdf.Validate() == nil; kdf.N *= 2 {
+			start := time.Now()
+			if _, err := scrypt.Key([]byte("aegis-bench"), salt, kdf.N, kdf.R, kdf.P, kdf.KeyLen); err != nil {
+				errorf("Error: %v\n", err)
+				os.Exit(exitFatal)
+			}
+			elapsed := time.Since(start)
+
+			mark := ""
+			if kdf.N == crypto.DefaultKDFParams.N {
+				mark = " (default)"
+			}
+			fmt.Printf("   %-10d %-10s %v%s\n", kdf.N, formatSize(int64(128*kdf.N*kdf.R)), elapsed.Round(time.Millisecond), mark)
+
+			if elapsed > benchTarget { // Each doubling of N roughly doubles the time; stop past the target.
+				break
+			}
+			best = kdf.N
+		}
+
+		fmt.Println()
+		if best == 0 {
+			fmt.Printf("%s Even N=%d takes longer than %v on this machine; consider a longer target or a lower r.\n", markWarn, benchMinN, benchTarget)
+			return
+		}
+		fmt.Printf("%s Recommended: N=%d (the highest tested value under %v)\n", markDone, best, benchTarget)
+		rec := benchKDF
+		rec.N = best
+		weak := ""
+		if rec.CheckStrength() != nil { // Seal refuses such settings unless told otherwise.
+			weak = " --allow-weak-kdf"
+		}
+		fmt.Printf("   aegis seal --scrypt-n %d --scrypt-r %d --scrypt-p %d%s [directory]\n", best, benchKDF.R, benchKDF.P, weak)
+		if weak != "" {
+			fmt.Printf("   Note: this is below the minimum seal accepts without --allow-weak-kdf (N=%d at r=%d) and leaves the password easy to guess.\n", crypto.MinKDFParams.N, crypto.MinKDFParams.R)
+		} else if best < crypto.DefaultKDFParams.N {
+			fmt.Printf("   Note: this is below the default N=%d and weakens resistance to password guessing.\n", crypto.DefaultKDFParams.N)
+		}
+	},
+}
+
+func init() {
+	benchKDFCmd.Flags().DurationVar(&benchTarget, "target", 500*time.Millisecond, "Longest acceptable key derivation time (e.g. 500ms, 1s)")
+	benchKDFCmd.Flags().IntVar(&benchKDF.R, "scrypt-r", crypto.DefaultKDFParams.R, "scrypt block size parameter r to benchmark with")
+	benchKDFCmd.Flags().IntVar(&benchKDF.P, "scrypt-p", crypto.DefaultKDFParams.P, "scrypt parallelization parameter p to benchmark with")
+	RootCmd.AddCommand(benchKDFCmd)
+}
@@ -0,0 +1,199 @@
+package cli
+
+import (
+	"aegis/internal/atomicfile"
+	"aegis/internal/crypto"
+	"aegis/pkg/aegis"
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// checksumManifestFile is the signed list of sealed files written by seal --checksum-manifest at
+// the root of the sealed tree. Each sealed file authenticates on its own, but only this list
+// notices a sealed file that was deleted, added or swapped for another one.
+const checksumManifestFile = aegis.ChecksumManifestFile
+
+// checksumPurpose binds the manifest's HMAC key to this use, apart from the encryption keys
+// derived from the same password.
+const checksumPurpose = "aegis checksum manifest v1"
+
+// errChecksumMismatch is returned when the manifest's HMAC does not match its content.
+var errChecksumMismatch = errors.New("HMAC mismatch: wrong password, or the manifest was modified")
+
+// checksumManifest maps each sealed file, by its path relative to the root and slash-separated,
+// to the size and SHA-256 of the sealed file itself, so it can be checked without decrypting.
+type checksumManifest struct {
+	KDF     crypto.KDFParams         `json:"kdf"`  // scrypt parameters of the HMAC key.
+	Salt    []byte                   `json:"salt"` // scrypt salt of the HMAC key.
+	Updated time.Time                `json:"updated"`
+	Files   map[string]manifestEntry `json:"files"`
+}
+
+// signedChecksums is the file's on-disk form: Body is the JSON encoding of a checksumManifest and
+// HMAC the hex HMAC-SHA256 of its compact form. The manifest is not encrypted; it only lists sealed names and sizes,
+// which the directory shows anyway.
+type signedChecksums struct {
+	Body json.RawMessage `json:"body"`
+	HMAC string          `json:"hmac"`
+}
+
+// checksummed reports whether the file at path is listed in a checksum manifest at root: every
+// .aegis file, and the name and seal manifests, which are sealed files too.
+func checksummed(path, root string) bool {
+	name := filepath.Base(path)
+	return strings.HasSuffix(name, ".aegis") || name == namesManifestFile || path == filepath.Join(root, sealManifestFile)
+}
+
+// readChecksumManifest reads the checksum manifest in dir and checks its HMAC with a key derived
+// from password. A directory without a manifest returns nil and no error.
+func readChecksumManifest(dir, password string) (*checksumManifest, error) {
+	data, err := os.ReadFile(filepath.Join(dir, checksumManifestFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var signed signedChecksums
+	if err := json.Unmarshal(data, &signed); err != nil {
+		return nil, fmt.Errorf("invalid %s: %v", checksumManifestFile, err)
+	}
+	var m checksumManifest
+	if err := json.Unmarshal(signed.Body, &m); err != nil { // Only the key parameters are used before the HMAC is checked.
+		return nil, fmt.Errorf("invalid %s: %v", checksumManifestFile, err)
+	}
+	key, err := crypto.MACKey(password, checksumPurpose, m.Salt, m.KDF)
+	if err != nil {
+		return nil, err
+	}
+	var body bytes.Buffer // Signed in compact form; the file itself is indented for reading.
+	if err := json.Compact(&body, signed.Body); err != nil {
+		return nil, fmt.Errorf("invalid %s: %v", checksumManifestFile, err)
+	}
+	want, err := hex.DecodeString(signed.HMAC)
+	if err != nil || !hmac.Equal(checksumHMAC(key, body.Bytes()), want) {
+		return nil, errChecksumMismatch
+	}
+	if m.Files == nil {
+		m.Files = make(map[string]manifestEntry)
+	}
+	return &m, nil
+}
+
+// saveChecksumManifest signs m with a key derived from password under a fresh salt and writes it
+// to dir atomically.
+func saveChecksumManifest(dir string, m *checksumManifest, password string, kdf crypto.KDFParams) error {
+	key, salt, err := crypto.NewMACKey(password, checksumPurpose, kdf)
+	if err != nil {
+		return err
+	}
+	m.KDF, m.Salt, m.Updated = kdf, salt, time.Now().UTC()
+	body, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(signedChecksums{Body: body, HMAC: hex.EncodeToString(checksumHMAC(key, body))}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return atomicfile.Write(filepath.Join(dir, checksumManifestFile), 0600, func(w io.Writer) error {
+		_, err := w.Write(append(data, '\n'))
+		return err
+	})
+}
+
+// checksumHMAC returns the HMAC-SHA256 of body under key.
+func checksumHMAC(key, body []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(body)
+	return mac.Sum(nil)
+}
+
+// recordChecksum hashes the sealed file at path and records it in m under its path relative to root.
+func recordChecksum(m *checksumManifest, root, path string) error {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return err
+	}
+	entry, err := hashFile(path)
+	if err != nil {
+		return err
+	}
+	m.Files[filepath.ToSlash(rel)] = entry
+	return nil
+}
+
+// verifyChecksums compares the sealed files under dir with the manifest and prints every
+// discrepancy. It returns the number of files checked and of files that are missing, changed or
+// not listed.
+func verifyChecksums(dir string, m *checksumManifest) (checked, problems int, err error) {
+	onDisk := make(map[string]string) // Relative path -> path of every checksummed file found.
+	err = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.Mode().IsRegular() && checksummed(path, dir) {
+			rel, err := filepath.Rel(dir, path)
+			if err != nil {
+				return err
+			}
+			onDisk[filepath.ToSlash(rel)] = path
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var listed []string
+	for rel := range m.Files {
+		listed = append(listed, rel)
+	}
+	sort.Strings(listed)
+	for _, rel := range listed {
+		path, ok := onDisk[rel]
+		if !ok {
+			errorf("%s MISSING '%s': listed in %s but not found\n", markError, rel, checksumManifestFile)
+			problems++
+			continue
+		}
+		checked++
+		got, err := hashFile(path)
+		switch {
+		case err != nil:
+			errorf("%s FAILED '%s': %v\n", markError, path, err)
+			problems++
+		case got.Size != m.Files[rel].Size || got.SHA256 != m.Files[rel].SHA256:
+			errorf("%s CHANGED '%s': does not match the checksum in %s\n", markError, path, checksumManifestFile)
+			problems++
+		default:
+			if !quiet {
+				fmt.Printf("%s OK '%s'\n", markOK, path)
+			}
+		}
+	}
+
+	var extra []string
+	for rel := range onDisk {
+		if _, ok := m.Files[rel]; !ok {
+			extra = append(extra, rel)
+		}
+	}
+	sort.Strings(extra)
+	for _, rel := range extra {
+		errorf("%s UNEXPECTED '%s': not listed in %s\n", markError, onDisk[rel], checksumManifestFile)
+		problems++
+	}
+	return checked, problems, nil
+}
@@ -0,0 +1,60 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// color is the SGR parameter of an ANSI foreground color.
+type color string
+
+const (
+	colorGreen  color = "32" // Success: sealed or unsealed files, created files.
+	colorRed    color = "31" // Failure: errors, failed counts, removed files.
+	colorYellow color = "33" // Warnings and modified, renamed or moved files.
+)
+
+// noColor is set by the persistent --no-color flag.
+var noColor bool
+
+// stdoutColor and stderrColor report whether output to each stream is colored. They are set by
+// setupColor; log files written by watch never are.
+var stdoutColor, stderrColor bool
+
+// setupColor enables color on each stream that is a terminal, unless --no-color is given or
+// NO_COLOR is set to a non-empty value (https://no-color.org).
+func setupColor() {
+	if noColor || os.Getenv("NO_COLOR") != "" {
+		return
+	}
+	stdoutColor = term.IsTerminal(int(os.Stdout.Fd()))
+	stderrColor = term.IsTerminal(int(os.Stderr.Fd()))
+}
+
+// colorize wraps every non-empty line of s in c. The newlines stay outside the escape codes, so
+// multi-line messages such as watch's event boxes are colored line by line.
+func colorize(c color, s string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = "\x1b[" + string(c) + "m" + line + "\x1b[0m"
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// paint returns s in c when stdout is colored, and s unchanged otherwise.
+func paint(c color, s string) string {
+	if !stdoutColor {
+		return s
+	}
+	return colorize(c, s)
+}
+
+// printColorf prints a line to stdout in c (when stdout is colored).
+func printColorf(c color, format string, a ...any) {
+	fmt.Print(paint(c, fmt.Sprintf(format, a...)))
+}
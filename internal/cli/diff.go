@@ -0,0 +1,207 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diffOpKind identifies one step of a line edit script
+type diffOpKind int
+
+const (
+	diffEqual diffOpKind = iota
+	diffDelete
+	diffInsert
+)
+
+// diffOp is one step of an edit script turning the old lines into the new ones.
+// oldIdx is valid for equal and delete steps, newIdx for equal and insert steps.
+type diffOp struct {
+	kind   diffOpKind
+	oldIdx int
+	newIdx int
+}
+
+// maxDiffEdits bounds the Myers search so huge rewrites cannot exhaust memory;
+// past this many edits the changed middle is reported as replaced wholesale.
+const maxDiffEdits = 2000
+
+// diffLines computes a shortest edit script between a and b using Myers' algorithm,
+// after trimming the common prefix and suffix.
+func diffLines(a, b []string) []diffOp {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	ops := make([]diffOp, 0, len(a)+len(b))
+	for i := 0; i < prefix; i++ {
+		ops = append(ops, diffOp{kind: diffEqual, oldIdx: i, newIdx: i})
+	}
+	for _, op := range myersDiff(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]) {
+		op.oldIdx += prefix
+		op.newIdx += prefix
+		ops = append(ops, op)
+	}
+	for i := suffix; i > 0; i-- {
+		ops = append(ops, diffOp{kind: diffEqual, oldIdx: len(a) - i, newIdx: len(b) - i})
+	}
+	return ops
+}
+
+// myersDiff returns the edit script for a and b, or a delete-all/insert-all script when
+// more than maxDiffEdits edits would be needed.
+func myersDiff(a, b []string) []diffOp {
+	n, m := len(a), len(b)
+	max := n + m
+	offset := max + 1
+	v := make([]int, 2*max+3)
+	var trace [][]int // trace[d] holds the furthest x for each diagonal k in [-d, d] after d edits.
+
+	for d := 0; d <= max && d <= maxDiffEdits; d++ {
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1] // Step down: insertion.
+			} else {
+				x = v[offset+k-1] + 1 // Step right: deletion.
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				trace = append(trace, append([]int(nil), v[offset-d:offset+d+1]...))
+				return backtrackDiff(trace, n, m)
+			}
+		}
+		trace = append(trace, append([]int(nil), v[offset-d:offset+d+1]...))
+	}
+
+	// Too many edits: report every old line as deleted and every new line as inserted.
+	ops := make([]diffOp, 0, n+m)
+	for i := 0; i < n; i++ {
+		ops = append(ops, diffOp{kind: diffDelete, oldIdx: i})
+	}
+	for j := 0; j < m; j++ {
+		ops = append(ops, diffOp{kind: diffInsert, newIdx: j})
+	}
+	return ops
+}
+
+// backtrackDiff walks the Myers trace from (n, m) back to the origin to recover the edit script.
+func backtrackDiff(trace [][]int, n, m int) []diffOp {
+	at := func(d, k int) int { return trace[d][k+d] }
+
+	var ops []diffOp
+	x, y := n, m
+	for d := len(trace) - 1; d > 0; d-- {
+		k := x - y
+		prevK := k - 1
+		if k == -d || (k != d && at(d-1, k-1) < at(d-1, k+1)) {
+			prevK = k + 1
+		}
+		prevX := at(d-1, prevK)
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			x--
+			y--
+			ops = append(ops, diffOp{kind: diffEqual, oldIdx: x, newIdx: y})
+		}
+		if x == prevX {
+			y--
+			ops = append(ops, diffOp{kind: diffInsert, newIdx: y})
+		} else {
+			x--
+			ops = append(ops, diffOp{kind: diffDelete, oldIdx: x})
+		}
+	}
+	for x > 0 && y > 0 {
+		x--
+		y--
+		ops = append(ops, diffOp{kind: diffEqual, oldIdx: x, newIdx: y})
+	}
+
+	for i, j := 0, len(ops)-1; i < j; i, j = i+1, j-1 {
+		ops[i], ops[j] = ops[j], ops[i]
+	}
+	return ops
+}
+
+// unifiedDiff renders the changes from a to b as unified diff hunks with the given number
+// of context lines, headed by "--- oldName" and "+++ newName". It returns "" when the
+// inputs are identical. A trailing empty element (from content ending in a newline) is
+// not treated as a line.
+func unifiedDiff(oldName, newName string, a, b []string, context int) string {
+	a, b = trimFinalEmpty(a), trimFinalEmpty(b)
+	ops := diffLines(a, b)
+
+	// oldPos[i] and newPos[i] count the old and new lines consumed before ops[i].
+	oldPos := make([]int, len(ops)+1)
+	newPos := make([]int, len(ops)+1)
+	var changes []int
+	for i, op := range ops {
+		oldPos[i+1], newPos[i+1] = oldPos[i], newPos[i]
+		if op.kind != diffInsert {
+			oldPos[i+1]++
+		}
+		if op.kind != diffDelete {
+			newPos[i+1]++
+		}
+		if op.kind != diffEqual {
+			changes = append(changes, i)
+		}
+	}
+	if len(changes) == 0 {
+		return ""
+	}
+
+	b2 := &strings.Builder{}
+	fmt.Fprintf(b2, "--- %s\n+++ %s\n", oldName, newName)
+	for ci := 0; ci < len(changes); {
+		// Merge changes whose separating context would overlap into a single hunk.
+		cj := ci
+		for cj+1 < len(changes) && changes[cj+1]-changes[cj]-1 <= 2*context {
+			cj++
+		}
+		start := max(changes[ci]-context, 0)
+		end := min(changes[cj]+context, len(ops)-1)
+
+		oldCount := oldPos[end+1] - oldPos[start]
+		newCount := newPos[end+1] - newPos[start]
+		oldStart, newStart := oldPos[start]+1, newPos[start]+1
+		if oldCount == 0 { // An empty range names the line before it.
+			oldStart--
+		}
+		if newCount == 0 {
+			newStart--
+		}
+		fmt.Fprintf(b2, "@@ -%d,%d +%d,%d @@\n", oldStart, oldCount, newStart, newCount)
+		for _, op := range ops[start : end+1] {
+			switch op.kind {
+			case diffEqual:
+				fmt.Fprintf(b2, " %s\n", a[op.oldIdx])
+			case diffDelete:
+				fmt.Fprintf(b2, "-%s\n", a[op.oldIdx])
+			case diffInsert:
+				fmt.Fprintf(b2, "+%s\n", b[op.newIdx])
+			}
+		}
+		ci = cj + 1
+	}
+	return b2.String()
+}
+
+// trimFinalEmpty drops the empty element strings.Split leaves after a final newline.
+func trimFinalEmpty(lines []string) []string {
+	if n := len(lines); n > 0 && lines[n-1] == "" {
+		return lines[:n-1]
+	}
+	return lines
+}
@@ -0,0 +1,75 @@
+package cli
+
+import "os"
+
+// noEmojiEnvVar, when set to a non-empty value, has the same effect as --no-emoji.
+const noEmojiEnvVar = "AEGIS_NO_EMOJI"
+
+// noEmoji is set by the persistent --no-emoji flag.
+var noEmoji bool
+
+// plainMarkers reports whether markers are printed as their ASCII tags. It is set by setupEmoji
+// and applies to stdout, stderr and watch's log files alike.
+var plainMarkers bool
+
+// marker is the symbol that starts a line of output: an emoji on a terminal, or a bracketed
+// ASCII tag with --no-emoji. Markers are printed with %s, so every message switches together.
+type marker struct {
+	emoji string // Includes a trailing space for the emoji that are padded with two spaces.
+	ascii string
+}
+
+// String returns the marker in the form selected by setupEmoji.
+func (m marker) String() string {
+	if plainMarkers {
+		return m.ascii
+	}
+	return m.emoji
+}
+
+// The markers used across the commands. Several share an emoji but not a meaning, so each has
+// its own tag.
+var (
+	markOK          = marker{"✅", "[OK]"}
+	markFail        = marker{"❌", "[FAIL]"}
+	markError       = marker{"⛔", "[ERROR]"} // Wrong password, failed authentication or integrity checks.
+	markFatal       = marker{"🔥", "[FATAL]"}
+	markWarn        = marker{"⚠️ ", "[WARN]"}
+	markInfo        = marker{"ℹ️ ", "[INFO]"}
+	markDone        = marker{"✨", "[DONE]"}
+	markStop        = marker{"🛑", "[STOP]"}
+	markDir         = marker{"📁", "[DIR]"}
+	markFile        = marker{"📄", "[FILE]"}
+	markList        = marker{"📋", "[LIST]"}
+	markStats       = marker{"📊", "[STATS]"}
+	markSeal        = marker{"🔒", "[SEAL]"}
+	markUnseal      = marker{"🔑", "[UNSEAL]"}
+	markRekey       = marker{"🔁", "[REKEY]"}
+	markVerify      = marker{"🔎", "[VERIFY]"}
+	markDryRun      = marker{"🔍", "[DRY-RUN]"}
+	markPack        = marker{"📦", "[PACK]"}
+	markTest        = marker{"🧪", "[TEST]"}
+	markTime        = marker{"🕐", "[TIME]"}
+	markTimer       = marker{"⏱️ ", "[TIME]"}
+	markDetailedLog = marker{"📝", "[LOG]"}
+	markBasicLog    = marker{"📋", "[LOG]"}
+	markPreview     = marker{"📝", "[PREVIEW]"}
+	markSnap        = marker{"📸", "[SNAPSHOT]"}
+	markWatch       = marker{"👀", "[WATCH]"}
+	markCreated     = marker{"➕", "[CREATED]"}
+	markRemoved     = marker{"➖", "[REMOVED]"}
+	markChanged     = marker{"📝", "[MODIFIED]"}
+	markRenamed     = marker{"🔄", "[RENAMED]"}
+	markMoved       = marker{"🔀", "[MOVED]"}
+	markEdited      = marker{"✏️ ", "[MODIFIED]"}
+	markChars       = marker{"🔤 ", "[CHARS]"}
+	markTruncated   = marker{"✂️ ", "[TRUNCATED]"}
+	markOffset      = marker{"📍", "[OFFSET]"}
+	markHook        = marker{"⚙️ ", "[HOOK]"}
+)
+
+// setupEmoji switches every marker to its ASCII tag when --no-emoji is given or AEGIS_NO_EMOJI
+// is set to a non-empty value.
+func setupEmoji() {
+	plainMarkers = noEmoji || os.Getenv(noEmojiEnvVar) != ""
+}
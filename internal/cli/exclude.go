@@ -0,0 +1,226 @@
+package cli
+
+import (
+	"aegis/pkg/aegis"
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// defaultExcludes are the directories seal skips unless --no-default-excludes is given.
+var defaultExcludes = []string{".git", "vendor", "node_modules", "target"}
+
+// findGitRepos returns the git metadata (".git" directories, or the ".git" files of worktrees and
+// submodules) a walk of root would reach, so seal can refuse to encrypt a working repository.
+func findGitRepos(root string, skipDir func(path string) bool) []string {
+	var found []string
+	filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		if info.IsDir() && path != root && skipDir(path) {
+			return filepath.SkipDir
+		}
+		if info.Name() == ".git" {
+			found = append(found, path)
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+		}
+		return nil
+	})
+	return found
+}
+
+// beyondMaxDepth reports whether a walk limited to maxDepth levels (--max-depth) stops at the
+// directory rel, given relative to the walk root. Files up to maxDepth levels down are visited,
+// so a directory that deep is not entered. A limit of 0 means no limit.
+func beyondMaxDepth(rel string, maxDepth int) bool {
+	if maxDepth <= 0 || rel == "." {
+		return false
+	}
+	return strings.Count(filepath.ToSlash(rel), "/")+1 >= maxDepth
+}
+
+// isWatchSessionDir reports whether dir is a session log folder written by watch: named after
+// the session's start time and holding that session's detailed log. Seal skips these, since a
+// running watch may still be writing to them.
+func isWatchSessionDir(dir string) bool {
+	name := filepath.Base(dir)
+	if _, err := time.Parse(watchSessionLayout, name); err != nil {
+		return false
+	}
+	info, err := os.Stat(filepath.Join(dir, watchDetailedLogName(name)))
+	return err == nil && info.Mode().IsRegular()
+}
+
+// ignoreFileName is the gitignore-style file read from the root of the directory being sealed.
+const ignoreFileName = ".aegisignore"
+
+// hiddenSecrets are dotfiles that --skip-hidden still seals because they usually hold
+// credentials. Entries ending in "*" match by prefix.
+var hiddenSecrets = []string{".env", ".env.*", ".netrc", ".pgpass", ".npmrc", ".pypirc"}
+
+// isHiddenSkipped reports whether --skip-hidden leaves the named item alone: its name starts
+// with "." and, for files, it is not one of the hiddenSecrets.
+func isHiddenSkipped(name string, isDir bool) bool {
+	if !strings.HasPrefix(name, ".") {
+		return false
+	}
+	if isDir {
+		return true
+	}
+	for _, secret := range hiddenSecrets {
+		if prefix, ok := strings.CutSuffix(secret, "*"); ok && strings.HasPrefix(name, prefix) || name == secret {
+			return false
+		}
+	}
+	return true
+}
+
+// excludeMatcher decides which paths seal skips. Patterns use filepath.Match syntax and are
+// matched against both the base name and the slash-separated path relative to the walk root.
+// A trailing "/" restricts a pattern to directories (e.g. "tmp/").
+type excludeMatcher struct {
+	patterns []excludePattern
+}
+
+type excludePattern struct {
+	glob     string
+	dirOnly  bool
+	anchored bool // Matched against the relative path only, never the bare name.
+}
+
+// newExcludeMatcher validates the patterns and returns a matcher for them.
+func newExcludeMatcher(patterns []string) (*excludeMatcher, error) {
+	m := &excludeMatcher{}
+	for _, p := range patterns {
+		if err := m.add(p); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// add appends one pattern, rejecting malformed globs up front instead of mid-walk.
+func (m *excludeMatcher) add(pattern string) error {
+	return m.addPattern(pattern, false)
+}
+
+// addPattern parses pattern; with gitignore set, a leading "/" or an inner "/" anchors the
+// pattern to the walk root, as in .gitignore.
+func (m *excludeMatcher) addPattern(pattern string, gitignore bool) error {
+	p := excludePattern{glob: filepath.ToSlash(pattern)}
+	if strings.HasSuffix(p.glob, "/") {
+		p.dirOnly = true
+		p.glob = strings.TrimSuffix(p.glob, "/")
+	}
+	if gitignore && strings.Contains(p.glob, "/") {
+		p.anchored = true
+		p.glob = strings.TrimPrefix(p.glob, "/")
+	}
+	if p.glob == "" {
+		return fmt.Errorf("empty exclude pattern %q", pattern)
+	}
+	if _, err := filepath.Match(p.glob, ""); err != nil {
+		return fmt.Errorf("invalid exclude pattern %q: %v", pattern, err)
+	}
+	m.patterns = append(m.patterns, p)
+	return nil
+}
+
+// loadIgnoreFile adds the patterns from dir's .aegisignore, if there is one. Blank lines and
+// lines starting with "#" are ignored. Only a subset of gitignore is supported: globs, a trailing
+// "/" for directories, and "/"-anchored paths; negation ("!") is rejected rather than misread.
+// It reports whether an ignore file was found.
+func (m *excludeMatcher) loadIgnoreFile(dir string) (bool, error) {
+	f, err := os.Open(filepath.Join(dir, ignoreFileName))
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		pattern := strings.TrimSpace(scanner.Text())
+		if pattern == "" || strings.HasPrefix(pattern, "#") {
+			continue
+		}
+		if strings.HasPrefix(pattern, "!") {
+			return true, fmt.Errorf("%s:%d: negated patterns are not supported", ignoreFileName, line)
+		}
+		if err := m.addPattern(pattern, true); err != nil {
+			return true, fmt.Errorf("%s:%d: %v", ignoreFileName, line, err)
+		}
+	}
+	return true, scanner.Err()
+}
+
+// match reports whether the path, given relative to the walk root, is excluded.
+func (m *excludeMatcher) match(rel string, isDir bool) bool {
+	rel = filepath.ToSlash(rel)
+	name := rel[strings.LastIndex(rel, "/")+1:]
+	for _, p := range m.patterns {
+		if p.dirOnly && !isDir {
+			continue
+		}
+		if !p.anchored {
+			if ok, _ := filepath.Match(p.glob, name); ok {
+				return true
+			}
+		}
+		if ok, _ := filepath.Match(p.glob, rel); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// extFilter selects the files seal handles by extension (--exclude-ext and --only-ext).
+// Extensions are compared case-insensitively and may be given with or without the leading dot.
+type extFilter struct {
+	exclude map[string]bool
+	only    map[string]bool // Empty means every extension not excluded is sealed.
+}
+
+// newExtFilter normalizes the listed extensions, rejecting ones that could never match.
+func newExtFilter(exclude, only []string) (*extFilter, error) {
+	f := &extFilter{exclude: make(map[string]bool), only: make(map[string]bool)}
+	for _, list := range []struct {
+		flag string
+		exts []string
+		set  map[string]bool
+	}{{"--exclude-ext", exclude, f.exclude}, {"--only-ext", only, f.only}} {
+		for _, ext := range list.exts {
+			norm := strings.ToLower(ext)
+			if !strings.HasPrefix(norm, ".") {
+				norm = "." + norm
+			}
+			if norm == "." || strings.ContainsAny(norm[1:], `./\`) {
+				return nil, fmt.Errorf("invalid %s %q: give a single extension such as md or .md", list.flag, ext)
+			}
+			list.set[norm] = true
+		}
+	}
+	return f, nil
+}
+
+// skip reports whether the file at path is filtered out by extension, and why. The extension
+// is the one seal records (aegis.SplitExt), so a dotfile such as .gitignore has none.
+func (f *extFilter) skip(path string) (bool, string) {
+	_, ext := aegis.SplitExt(filepath.Base(path))
+	ext = strings.ToLower(ext)
+	if f.exclude[ext] {
+		return true, "excluded extension"
+	}
+	if len(f.only) > 0 && !f.only[ext] {
+		return true, "extension not selected by --only-ext"
+	}
+	return false, ""
+}
@@ -0,0 +1,28 @@
+package cli
+
+import "testing"
+
+func TestExtFilterSkip(t *testing.T) {
+	tests := []struct {
+		exclude, only []string
+		path          string
+		skip          bool
+	}{
+		{[]string{"gitignore"}, nil, "dir/.gitignore", false}, // A dotfile has no extension.
+		{[]string{"md"}, nil, "dir/README.MD", true},
+		{[]string{".gz"}, nil, "archive.tar.gz", true},
+		{[]string{"tar"}, nil, "archive.tar.gz", false},
+		{[]string{"local"}, nil, ".env.local", true},
+		{nil, []string{"txt"}, ".gitignore", true},
+		{nil, []string{"txt"}, "notes.txt", false},
+	}
+	for _, tt := range tests {
+		f, err := newExtFilter(tt.exclude, tt.only)
+		if err != nil {
+			t.Fatalf("newExtFilter(%q, %q): %v", tt.exclude, tt.only, err)
+		}
+		if skip, _ := f.skip(tt.path); skip != tt.skip {
+			t.Errorf("skip(%q) with --exclude-ext %q --only-ext %q = %v, want %v", tt.path, tt.exclude, tt.only, skip, tt.skip)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package cli
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// fileFailure is one file a command could not process, with the reason already shown inline.
+type fileFailure struct {
+	Path   string `json:"path"`
+	Reason string `json:"reason"`
+}
+
+// failureList collects the per-file failures of a seal or unseal run, so they can be listed
+// together at the end instead of scrolling away between the progress lines. It is safe for
+// use by unseal's concurrent workers.
+type failureList struct {
+	mu    sync.Mutex
+	items []fileFailure
+}
+
+// add records a failure for path; the reason is formatted like the inline message.
+func (l *failureList) add(path, format string, a ...any) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.items = append(l.items, fileFailure{Path: path, Reason: fmt.Sprintf(format, a...)})
+}
+
+// count returns the number of failures recorded so far.
+func (l *failureList) count() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.items)
+}
+
+// sorted returns the failures sorted by path, so reports read the same whatever order the
+// files were processed in. It never returns nil.
+func (l *failureList) sorted() []fileFailure {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	items := append([]fileFailure{}, l.items...)
+	sort.SliceStable(items, func(i, j int) bool { return items[i].Path < items[j].Path })
+	return items
+}
+
+// print lists every failure under a "Failures:" heading. Nothing is printed without failures.
+func (l *failureList) print() {
+	items := l.sorted()
+	if len(items) == 0 {
+		return
+	}
+	printColorf(colorRed, "\n   Failures:\n")
+	for _, f := range items {
+		fmt.Printf("   %s %s: %s\n", markFail, f.Path, f.Reason)
+	}
+}
@@ -0,0 +1,31 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// interruptContext returns a context that is canceled on the first Ctrl+C or SIGTERM, so seal
+// and unseal stop at the next file instead of dying mid-run. Files already being written are
+// finished (every write is atomic either way); a second signal ends the process at once. The
+// returned function releases the signal handler.
+func interruptContext() (context.Context, func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		select {
+		case sig := <-signals:
+			signal.Stop(signals) // Restores the default handling for the second signal.
+			warnf("\n%s Received %v; stopping after the current file (press Ctrl+C again to quit now)...\n", markStop, sig)
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, func() {
+		signal.Stop(signals)
+		cancel()
+	}
+}
@@ -0,0 +1,139 @@
+package cli
+
+import (
+	"aegis/internal/crypto"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// listPasswordFile holds the --password-file path; when set it takes precedence over other password sources.
+var listPasswordFile string
+
+// listKeyfile holds the --keyfile path, needed when the manifests were sealed with seal --keyfile.
+var listKeyfile string
+
+var listCmd = &cobra.Command{
+	Use:   "list [directory]",
+	Short: "List the original files in a sealed directory",
+	Long: `Print the original relative paths, sizes and modification times of the files sealed in a directory,
+read from the encrypted ` + sealManifestFile + ` written by seal --manifest. File contents are not decrypted.
+Without a manifest, the .aegis files are listed instead, with their original names when --hide-names
+recorded them, and the sizes and times of the sealed files.`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		dir := args[0]
+
+		keyfile, err := readKeyfile(listKeyfile)
+		if err != nil {
+			errorf("Error: %v\n", err)
+			os.Exit(exitFatal)
+		}
+		password, err := readPassword(listPasswordFile, false)
+		if err != nil {
+			errorf("Error reading password: %v\n", err)
+			os.Exit(exitFatal)
+		}
+		keys := crypto.NewKeyfileKeyCache(password, keyfile)
+
+		m, err := readSealManifest(dir, keys)
+		if errors.Is(err, crypto.ErrDecryptFailed) {
+			errorf("%s Could not read %s: %s\n", markError, sealManifestFile, decryptFailure(err, keyfile))
+			os.Exit(exitFatal)
+		}
+		if err != nil {
+			errorf("Error: could not read %s: %v\n", sealManifestFile, err)
+			os.Exit(exitFatal)
+		}
+		if m == nil {
+			listSealedFiles(dir, keys)
+			return
+		}
+
+		fmt.Printf("%s Contents of '%s' (from %s, updated %s)\n\n", markList, dir, sealManifestFile, m.Updated.Local().Format("2006-01-02 15:04:05"))
+		paths := make([]string, 0, len(m.Files))
+		for rel := range m.Files {
+			paths = append(paths, rel)
+		}
+		sort.Strings(paths)
+		var total int64
+		for _, rel := range paths {
+			entry := m.Files[rel]
+			name := filepath.FromSlash(rel)
+			if target, ok := m.Links[rel]; ok {
+				name += fmt.Sprintf(" (sealed through symlink to '%s')", target)
+			}
+			printListEntry(formatSize(entry.Size), entry.ModTime, name)
+			total += entry.Size
+		}
+		for _, rel := range m.Dirs {
+			printListEntry("-", time.Time{}, filepath.FromSlash(rel)+string(filepath.Separator))
+		}
+
+		fmt.Printf("\n%s %d files, %s.\n", markDone, len(paths), formatSize(total))
+		if len(m.Dirs) > 0 {
+			fmt.Printf("   %d directories recorded by --include-empty-dirs.\n", len(m.Dirs))
+		}
+	},
+}
+
+// listSealedFiles lists the .aegis files under dir for a tree sealed without --manifest. Hidden
+// names are resolved through each directory's name manifest; sizes and times are those of the
+// sealed files, since the originals' are only known inside the encrypted content.
+func listSealedFiles(dir string, keys *crypto.KeyCache) {
+	fmt.Printf("%s Sealed files in '%s' (no %s; sizes and times are of the sealed files)\n\n", markList, dir, sealManifestFile)
+	names := make(map[string]nameManifest) // Directory -> its name manifest, read on first use.
+	var count int
+	var total int64
+	walkErr := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || !strings.HasSuffix(path, ".aegis") {
+			return nil
+		}
+		parent := filepath.Dir(path)
+		manifest, ok := names[parent]
+		if !ok {
+			if manifest, err = readNameManifest(parent, keys); err != nil {
+				warnf("Warning: Could not read %s: %v. Showing sealed names.\n", filepath.Join(parent, namesManifestFile), err)
+			}
+			names[parent] = manifest
+		}
+		rel, _ := filepath.Rel(dir, path)
+		if original, ok := manifest[info.Name()]; ok {
+			rel = filepath.Join(filepath.Dir(rel), original) + " (sealed as " + info.Name() + ")"
+		}
+		printListEntry(formatSize(info.Size()), info.ModTime(), rel)
+		count++
+		total += info.Size()
+		return nil
+	})
+	if walkErr != nil {
+		errorf("\n\n%s Fatal Error during listing: %v\n", markFatal, walkErr)
+		os.Exit(exitFatal)
+	}
+	fmt.Printf("\n%s %d sealed files, %s.\n", markDone, count, formatSize(total))
+}
+
+// printListEntry prints one row of the listing; a zero modTime (manifests written before
+// modification times were recorded, and directories) is shown as "-".
+func printListEntry(size string, modTime time.Time, name string) {
+	when := "-"
+	if !modTime.IsZero() {
+		when = modTime.Local().Format("2006-01-02 15:04")
+	}
+	fmt.Printf("   %10s  %-16s  %s\n", size, when, name)
+}
+
+func init() {
+	listCmd.Flags().StringVar(&listPasswordFile, "password-file", "", "Read the password from the first line of this file")
+	listCmd.Flags().StringVar(&listKeyfile, "keyfile", "", "Key file given to seal --keyfile, for manifests sealed with one")
+	RootCmd.AddCommand(listCmd)
+}
@@ -0,0 +1,127 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// logLevel orders the severities of diagnostics written to stderr.
+type logLevel int
+
+const (
+	levelDebug logLevel = iota // Internal details: key derivation, format versions, password checks.
+	levelInfo                  // The default: everything except debug detail.
+	levelWarn                  // Something was skipped or only partly restored; the run continues.
+	levelError                 // A file or the whole command failed.
+)
+
+// logLevelNames maps the values accepted by --log-level to their levels.
+var logLevelNames = map[string]logLevel{
+	"debug": levelDebug,
+	"info":  levelInfo,
+	"warn":  levelWarn,
+	"error": levelError,
+}
+
+// logLevelFlag holds --log-level; minLogLevel is the parsed threshold applied by logf.
+var logLevelFlag string
+var minLogLevel = levelInfo
+
+// logOutput receives every diagnostic. User-facing progress and summaries stay on stdout.
+var logOutput io.Writer = os.Stderr
+
+// setLogLevel parses a --log-level value and makes it the threshold.
+func setLogLevel(name string) error {
+	level, ok := logLevelNames[strings.ToLower(name)]
+	if !ok {
+		return fmt.Errorf("invalid --log-level %q (use debug, info, warn or error)", name)
+	}
+	minLogLevel = level
+	return nil
+}
+
+// logf writes a diagnostic to stderr when level is at or above --log-level. Unlike per-file
+// progress lines, diagnostics are not silenced by --quiet. On a terminal, warnings are yellow
+// and errors red.
+func logf(level logLevel, format string, a ...any) {
+	if level < minLogLevel {
+		return
+	}
+	msg := fmt.Sprintf(format, a...)
+	if stderrColor && logOutput == os.Stderr {
+		switch level {
+		case levelWarn:
+			msg = colorize(colorYellow, msg)
+		case levelError:
+			msg = colorize(colorRed, msg)
+		}
+	}
+	fmt.Fprint(logOutput, msg)
+}
+
+// debugf logs an internal detail, prefixed so it stands apart from regular messages.
+func debugf(format string, a ...any) {
+	logf(levelDebug, "debug: "+format, a...)
+}
+
+// warnf logs a problem that did not stop the run.
+func warnf(format string, a ...any) {
+	logf(levelWarn, format, a...)
+}
+
+// errorf logs a failure.
+func errorf(format string, a ...any) {
+	logf(levelError, format, a...)
+}
+
+// fileLog collects the messages about one file so they can be printed together, in order, once
+// the file is done. Parallel unseal workers flush theirs through progress.report, which keeps one
+// file's lines from interleaving with another's.
+type fileLog struct {
+	msgs []func()
+}
+
+// printf queues a per-file line for stdout.
+func (l *fileLog) printf(format string, a ...any) {
+	l.msgs = append(l.msgs, func() { fmt.Printf(format, a...) })
+}
+
+// printColorf queues a per-file line for stdout in c.
+func (l *fileLog) printColorf(c color, format string, a ...any) {
+	l.msgs = append(l.msgs, func() { printColorf(c, format, a...) })
+}
+
+// println queues a per-file line for stdout.
+func (l *fileLog) println(a ...any) {
+	l.msgs = append(l.msgs, func() { fmt.Println(a...) })
+}
+
+// verbosef queues a per-file line shown only with --verbose.
+func (l *fileLog) verbosef(format string, a ...any) {
+	l.msgs = append(l.msgs, func() { verbosef(format, a...) })
+}
+
+// debugf queues a debug diagnostic.
+func (l *fileLog) debugf(format string, a ...any) {
+	l.msgs = append(l.msgs, func() { debugf(format, a...) })
+}
+
+// warnf queues a warning.
+func (l *fileLog) warnf(format string, a ...any) {
+	l.msgs = append(l.msgs, func() { warnf(format, a...) })
+}
+
+// errorf queues a failure.
+func (l *fileLog) errorf(format string, a ...any) {
+	l.msgs = append(l.msgs, func() { errorf(format, a...) })
+}
+
+// flush prints the queued messages; the level filter is applied as each one is written.
+func (l *fileLog) flush() {
+	for _, m := range l.msgs {
+		m()
+	}
+	l.msgs = nil
+}
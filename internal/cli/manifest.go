@@ -0,0 +1,248 @@
+package cli
+
+import (
+	"aegis/internal/crypto"
+	"aegis/pkg/aegis"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// sealManifestFile is the encrypted manifest written by seal --manifest at the root of the
+// sealed tree. It records every sealed file so unseal can check that nothing went missing.
+const sealManifestFile = aegis.ManifestFile
+
+// manifestEntry describes one sealed file by its original content.
+type manifestEntry struct {
+	Size    int64     `json:"size"`
+	SHA256  string    `json:"sha256"`
+	ModTime time.Time `json:"mod_time,omitzero"` // Shown by list; not checked, since unseal restores it from the header.
+}
+
+// sealManifest maps each sealed file's original path, relative to the root and slash-separated,
+// to its size and hash.
+type sealManifest struct {
+	Updated time.Time                `json:"updated"`
+	Files   map[string]manifestEntry `json:"files"`
+	Links   map[string]string        `json:"links,omitempty"` // Files sealed through --follow-symlinks -> link target.
+	Dirs    []string                 `json:"dirs,omitempty"`  // Directories recorded by --include-empty-dirs, sorted.
+}
+
+// hashFile returns the size and hex SHA-256 of the file at path.
+func hashFile(path string) (manifestEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return manifestEntry{}, err
+	}
+	defer f.Close()
+	h := sha256.New()
+	n, err := io.Copy(h, f)
+	if err != nil {
+		return manifestEntry{}, err
+	}
+	return manifestEntry{Size: n, SHA256: hex.EncodeToString(h.Sum(nil))}, nil
+}
+
+// hashingReader hashes the bytes read through it, so unseal can check restored content
+// against the manifest without reading it a second time.
+type hashingReader struct {
+	r    io.Reader
+	h    hash.Hash
+	size int64
+}
+
+func newHashingReader(r io.Reader) *hashingReader {
+	return &hashingReader{r: r, h: sha256.New()}
+}
+
+func (hr *hashingReader) Read(p []byte) (int, error) {
+	n, err := hr.r.Read(p)
+	hr.h.Write(p[:n])
+	hr.size += int64(n)
+	return n, err
+}
+
+// entry returns the size and hash of everything read so far.
+func (hr *hashingReader) entry() manifestEntry {
+	return manifestEntry{Size: hr.size, SHA256: hex.EncodeToString(hr.h.Sum(nil))}
+}
+
+// readSealManifest decrypts the manifest at the root of dir. A tree without a manifest
+// returns nil and no error.
+func readSealManifest(dir string, keys *crypto.KeyCache) (*sealManifest, error) {
+	var m sealManifest
+	found, err := readSealedJSON(filepath.Join(dir, sealManifestFile), keys, &m)
+	if err != nil || !found {
+		return nil, err
+	}
+	if m.Files == nil {
+		m.Files = make(map[string]manifestEntry)
+	}
+	return &m, nil
+}
+
+// saveSealManifest merges the files, link targets and directories recorded by this run into the
+// manifest left by an earlier run, if any, and writes it back encrypted. key may be nil in
+// --per-file-salt mode, in which case a dedicated key is derived for the manifest.
+func saveSealManifest(dir string, add *sealManifest, password string, keyfile []byte, key *crypto.Key) error {
+	m, err := readSealManifest(dir, crypto.NewKeyfileKeyCache(password, keyfile))
+	if err != nil {
+		return fmt.Errorf("could not read existing manifest: %v", err)
+	}
+	if m == nil {
+		m = &sealManifest{Files: make(map[string]manifestEntry)}
+	}
+	for rel, entry := range add.Files {
+		m.Files[rel] = entry
+		if target, ok := add.Links[rel]; ok {
+			if m.Links == nil {
+				m.Links = make(map[string]string)
+			}
+			m.Links[rel] = target
+		} else {
+			delete(m.Links, rel) // Sealed again as a regular file.
+		}
+	}
+	if len(add.Dirs) > 0 {
+		dirs := make(map[string]bool)
+		for _, rel := range append(m.Dirs, add.Dirs...) {
+			dirs[rel] = true
+		}
+		m.Dirs = m.Dirs[:0]
+		for rel := range dirs {
+			m.Dirs = append(m.Dirs, rel)
+		}
+		sort.Strings(m.Dirs)
+	}
+	m.Updated = time.Now().UTC()
+	if key == nil {
+		if key, err = crypto.NewKeyfileKey(password, keyfile, sealKDF); err != nil {
+			return err
+		}
+	}
+	return writeSealedJSON(filepath.Join(dir, sealManifestFile), m, key)
+}
+
+// restoreDirs creates the directories recorded by seal --include-empty-dirs that are missing
+// under root, and returns how many it created (with dryRun, how many it would create). Parents of
+// restored files (relative paths, as in the manifest) already exist, or would after a real run,
+// and are not counted. Entries that would land outside root are ignored.
+func restoreDirs(dirs []string, root string, restored map[string]manifestEntry, dryRun bool) int {
+	parents := make(map[string]bool)
+	for rel := range restored {
+		for d := path.Dir(rel); d != "."; d = path.Dir(d) {
+			parents[d] = true
+		}
+	}
+	created := 0
+	for _, rel := range dirs {
+		if parents[rel] || !filepath.IsLocal(filepath.FromSlash(rel)) {
+			continue
+		}
+		target := filepath.Join(root, filepath.FromSlash(rel))
+		if _, err := os.Lstat(target); err == nil {
+			continue
+		}
+		if dryRun {
+			fmt.Printf("   Would create directory: %s\n", target)
+			created++
+			continue
+		}
+		if err := os.MkdirAll(target, 0700); err != nil {
+			warnf("Warning: Failed to recreate directory %s: %v\n", target, err)
+			continue
+		}
+		if !quiet {
+			fmt.Printf("%s Recreated directory '%s'\n", markDir, target)
+		}
+		created++
+	}
+	return created
+}
+
+// checkSealManifest compares the files restored by unseal (relative path -> size and hash)
+// against the manifest and prints every discrepancy. It returns the number of manifest
+// entries that were not restored or whose content differs. A listed file that this run did not
+// restore still counts when the file under root matches, as after resuming an interrupted unseal.
+func checkSealManifest(m *sealManifest, restored map[string]manifestEntry, root string) int {
+	var paths []string
+	for rel := range m.Files {
+		paths = append(paths, rel)
+	}
+	sort.Strings(paths)
+
+	problems := 0
+	for _, rel := range paths {
+		got, ok := restored[rel]
+		if !ok {
+			onDisk, err := hashFile(filepath.Join(root, filepath.FromSlash(rel)))
+			if err == nil && onDisk.Size == m.Files[rel].Size && onDisk.SHA256 == m.Files[rel].SHA256 {
+				continue // Restored by an earlier run.
+			}
+		}
+		switch {
+		case !ok:
+			errorf("%s Manifest: '%s' was not restored (missing or failed)\n", markError, rel)
+			problems++
+		case got.Size != m.Files[rel].Size || got.SHA256 != m.Files[rel].SHA256:
+			errorf("%s Manifest: '%s' does not match the sealed content\n", markError, rel)
+			problems++
+		}
+	}
+	var extra []string
+	for rel := range restored {
+		if _, ok := m.Files[rel]; !ok {
+			extra = append(extra, rel)
+		}
+	}
+	sort.Strings(extra)
+	for _, rel := range extra {
+		warnf("Warning: '%s' is not listed in the manifest\n", rel)
+	}
+	return problems
+}
+
+// readSealedJSON decrypts the sealed file at path and unmarshals its content into v. A missing
+// file reports false and no error.
+func readSealedJSON(path string, keys *crypto.KeyCache, v any) (bool, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	payload, err := crypto.Open(f, filepath.Base(path), keys)
+	if err != nil {
+		return false, err
+	}
+	data, err := io.ReadAll(payload.Content)
+	if err != nil {
+		return false, err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return false, fmt.Errorf("invalid %s: %v", filepath.Base(path), err)
+	}
+	return true, nil
+}
+
+// writeSealedJSON marshals v and writes it to path as a sealed file under key.
+func writeSealedJSON(path string, v any, key *crypto.Key) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	meta := crypto.Metadata{Mode: 0600, ModTime: time.Now()}
+	return aegis.WriteSealed(path, meta, bytes.NewReader(data), int64(len(data)), false, key)
+}
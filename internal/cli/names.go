@@ -0,0 +1,42 @@
+package cli
+
+import (
+	"aegis/internal/crypto"
+	"aegis/pkg/aegis"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"path/filepath"
+)
+
+// namesManifestFile is the per-directory encrypted manifest written by --hide-names. It maps
+// each tokenized .aegis file in the directory to the file's original name.
+const namesManifestFile = aegis.NamesManifestFile
+
+// nameManifest maps a sealed file's base name (e.g. "3f9c...e1.aegis") to its original name.
+type nameManifest map[string]string
+
+// newHiddenName returns a random token used as the sealed file's base name.
+func newHiddenName() (string, error) {
+	token := make([]byte, 12)
+	if _, err := rand.Read(token); err != nil {
+		return "", fmt.Errorf("failed to generate hidden name: %v", err)
+	}
+	return hex.EncodeToString(token) + ".aegis", nil
+}
+
+// readNameManifest decrypts the name manifest in dir. A directory without a manifest
+// returns a nil map and no error.
+func readNameManifest(dir string, keys *crypto.KeyCache) (nameManifest, error) {
+	var names nameManifest
+	if _, err := readSealedJSON(filepath.Join(dir, namesManifestFile), keys, &names); err != nil {
+		return nil, err
+	}
+	return names, nil
+}
+
+// writeNameManifest encrypts the names with key and writes them as dir's name manifest,
+// replacing any previous manifest.
+func writeNameManifest(dir string, names nameManifest, key *crypto.Key) error {
+	return writeSealedJSON(filepath.Join(dir, namesManifestFile), names, key)
+}
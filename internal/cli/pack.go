@@ -0,0 +1,273 @@
+package cli
+
+import (
+	"aegis/internal/atomicfile"
+	"aegis/internal/crypto"
+	"archive/tar"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// packOutput holds --output: the bundle file to write.
+var packOutput string
+
+// packPasswordFile holds the --password-file path; when set it takes precedence over other password sources.
+var packPasswordFile string
+
+// packExcludes holds the repeatable --exclude glob patterns, applied in addition to the defaults.
+var packExcludes []string
+
+// packNoDefaultExcludes, when set via --no-default-excludes, disables the built-in exclude list.
+var packNoDefaultExcludes bool
+
+// bundleExt is the extension recorded for the archive inside a bundle. Unseal restores a bundle
+// as a plain .tar file, and unpack refuses sealed files that do not carry it.
+const bundleExt = ".tar"
+
+// packEntry is one directory or regular file stored in a bundle.
+type packEntry struct {
+	path string // Location on disk.
+	name string // Slash-separated path inside the archive.
+	info os.FileInfo
+}
+
+var packCmd = &cobra.Command{
+	Use:   "pack [directory] -o bundle.aegis",
+	Short: "Seal a directory into a single encrypted bundle",
+	Long: `Archive a directory (paths, permissions and modification times) as a tar stream and seal it into one
+.aegis file, so an encrypted snapshot can be moved around as a single unit. The directory itself is left
+untouched; restore the bundle with 'aegis unpack'. The tar stream is encrypted as it is produced and never
+touches the disk in plaintext.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		dir := "."
+		if len(args) > 0 {
+			dir = args[0]
+		}
+		if packOutput == "" {
+			errorf("Error: --output is required\n")
+			os.Exit(exitFatal)
+		}
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			errorf("Error: '%s' is not a directory\n", dir)
+			os.Exit(exitFatal)
+		}
+
+		var excludeList []string
+		if !packNoDefaultExcludes {
+			excludeList = append(excludeList, defaultExcludes...)
+		}
+		excludes, err := newExcludeMatcher(append(excludeList, packExcludes...))
+		if err != nil {
+			errorf("Error: %v\n", err)
+			os.Exit(exitFatal)
+		}
+		if _, err := excludes.loadIgnoreFile(dir); err != nil {
+			errorf("Error: %v\n", err)
+			os.Exit(exitFatal)
+		}
+
+		if !quiet {
+			fmt.Printf("%s Packing directory '%s' into '%s'...\n", markPack, dir, packOutput)
+		}
+		entries, skipped, err := collectPackEntries(dir, excludes)
+		if err != nil {
+			errorf("Error: %v\n", err)
+			os.Exit(exitFatal)
+		}
+
+		password, err := readPassword(packPasswordFile, true)
+		if err != nil {
+			errorf("Error reading password: %v\n", err)
+			os.Exit(exitFatal)
+		}
+		key, err := crypto.NewKey(password, crypto.DefaultKDFParams)
+		if err != nil {
+			errorf("Error: %v\n", err)
+			os.Exit(exitFatal)
+		}
+
+		if err := writeBundle(packOutput, entries, key); err != nil {
+			errorf("%s Failed to pack '%s': %v\n", markFail, dir, err)
+			os.Exit(exitFatal)
+		}
+
+		files := 0
+		var size int64
+		for _, e := range entries {
+			if e.info.Mode().IsRegular() {
+				files++
+				size += e.info.Size()
+			}
+		}
+		fmt.Printf("\n%s Packing complete: '%s'.\n", markDone, packOutput)
+		fmt.Printf("   Packed %d files (%s) from '%s'.\n", files, formatSize(size), dir)
+		if skipped > 0 {
+			fmt.Printf("   Skipped %d items (excluded, symlinks or special files).\n", skipped)
+		}
+	},
+}
+
+// collectPackEntries walks dir and returns the directories and regular files to archive, in walk
+// order. Excluded items, symlinks, special files, the bundle itself and leftovers from interrupted
+// writes are left out; the count of reported skips is returned alongside.
+func collectPackEntries(dir string, excludes *excludeMatcher) ([]packEntry, int, error) {
+	outputAbs, _ := filepath.Abs(packOutput)
+	var entries []packEntry
+	skipped := 0
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == dir {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		if excludes.match(rel, info.IsDir()) {
+			verbosef("   Skipping (excluded): %s\n", path)
+			skipped++
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		switch {
+		case info.IsDir():
+		case info.Mode()&os.ModeSymlink != 0:
+			if !quiet {
+				fmt.Printf("   Skipping (symlink): %s\n", path)
+			}
+			skipped++
+			return nil
+		case !info.Mode().IsRegular():
+			if !quiet {
+				fmt.Printf("   Skipping (special file): %s\n", path)
+			}
+			skipped++
+			return nil
+		case atomicfile.IsTemp(info.Name()):
+			verbosef("   Skipping (temporary file from an interrupted write): %s\n", path)
+			return nil
+		default:
+			if abs, _ := filepath.Abs(path); abs == outputAbs { // The bundle is being written inside the directory.
+				verbosef("   Skipping (output bundle): %s\n", path)
+				return nil
+			}
+		}
+		entries = append(entries, packEntry{path: path, name: filepath.ToSlash(rel), info: info})
+		return nil
+	})
+	return entries, skipped, err
+}
+
+// writeBundle seals the tar archive of entries into out. The archive is measured first without
+// reading any file content (the tar layout depends only on the headers and sizes), then produced
+// again with the content and encrypted as it streams, so plaintext never touches the disk.
+func writeBundle(out string, entries []packEntry, key *crypto.Key) error {
+	var size countingWriter
+	if err := writeTar(&size, entries, false); err != nil {
+		return err
+	}
+
+	meta := crypto.Metadata{Mode: 0600, ModTime: time.Now(), Ext: bundleExt}
+	return atomicfile.Write(out, 0600, func(w io.Writer) error {
+		pr, pw := io.Pipe()
+		done := make(chan error, 1)
+		go func() {
+			err := writeTar(pw, entries, true)
+			pw.CloseWithError(err)
+			done <- err
+		}()
+		err := crypto.Seal(w, filepath.Base(out), meta, pr, int64(size), false, key) // Binds the bundle's file name.
+		pr.Close()                                                                   // Unblocks the archiver if sealing stopped early.
+		if tarErr := <-done; tarErr != nil && !errors.Is(tarErr, io.ErrClosedPipe) {
+			return tarErr // More precise than the short read it caused.
+		}
+		return err
+	})
+}
+
+// writeTar writes the tar archive of entries to w. Without content, every file is written as
+// zeros of its recorded size, which produces an archive of exactly the same length.
+func writeTar(w io.Writer, entries []packEntry, content bool) error {
+	tw := tar.NewWriter(w)
+	for _, e := range entries {
+		hdr, err := tar.FileInfoHeader(e.info, "")
+		if err != nil {
+			return err
+		}
+		hdr.Name = e.name
+		if e.info.IsDir() {
+			hdr.Name += "/"
+		}
+		hdr.Format = tar.FormatPAX                            // Keeps sub-second modification times.
+		hdr.Uid, hdr.Gid, hdr.Uname, hdr.Gname = 0, 0, "", "" // Ownership is not restored; do not leak it.
+		hdr.AccessTime, hdr.ChangeTime = time.Time{}, time.Time{}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return fmt.Errorf("%s: %w", e.path, err)
+		}
+		if !e.info.Mode().IsRegular() {
+			continue
+		}
+		if !content {
+			if _, err := io.CopyN(tw, zeroReader{}, hdr.Size); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := copyFileInto(tw, e.path, hdr.Size); err != nil {
+			return fmt.Errorf("%s: %w", e.path, err)
+		}
+	}
+	return tw.Close()
+}
+
+// copyFileInto copies exactly size bytes of the file at path into w. A file that shrank since it
+// was measured is an error; bytes appended since are not included.
+func copyFileInto(w io.Writer, path string, size int64) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if _, err := io.CopyN(w, f, size); err != nil {
+		if err == io.EOF {
+			return fmt.Errorf("file changed while packing")
+		}
+		return err
+	}
+	return nil
+}
+
+// countingWriter discards what is written to it and counts the bytes.
+type countingWriter int64
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	*c += countingWriter(len(p))
+	return len(p), nil
+}
+
+// zeroReader is an endless source of zero bytes.
+type zeroReader struct{}
+
+func (zeroReader) Read(p []byte) (int, error) {
+	clear(p)
+	return len(p), nil
+}
+
+func init() {
+	packCmd.Flags().StringVarP(&packOutput, "output", "o", "", "Bundle file to write (required), e.g. backup.aegis")
+	packCmd.Flags().StringVar(&packPasswordFile, "password-file", "", "Read the password from the first line of this file")
+	packCmd.Flags().StringArrayVar(&packExcludes, "exclude", nil, "Leave out files and directories matching this glob (repeatable; a trailing / matches directories only)")
+	packCmd.Flags().BoolVar(&packNoDefaultExcludes, "no-default-excludes", false, "Do not skip the built-in directories (.git, vendor, node_modules, target)")
+	RootCmd.AddCommand(packCmd)
+}
@@ -0,0 +1,168 @@
+package cli
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// passwordEnvVar names the environment variable consulted before prompting for a password.
+const passwordEnvVar = "AEGIS_PASSWORD"
+
+// newPasswordEnvVar supplies the replacement password for rekey non-interactively.
+const newPasswordEnvVar = "AEGIS_NEW_PASSWORD"
+
+// errPasswordMismatch is returned when the confirmation prompt does not match the first entry.
+var errPasswordMismatch = errors.New("passwords do not match")
+
+// readPassword resolves the password from, in order of precedence, the given password
+// file, the AEGIS_PASSWORD environment variable, or an interactive terminal prompt.
+// When confirm is set, the interactive path asks for the password a second time.
+func readPassword(passwordFile string, confirm bool) (string, error) {
+	return readPasswordFrom(passwordFile, passwordEnvVar, "Enter password: ", confirm, 0)
+}
+
+// readPasswordFrom is readPassword with a configurable environment variable and prompt. When
+// the confirmation does not match, both entries are asked for again up to retries more times.
+func readPasswordFrom(passwordFile, envVar, prompt string, confirm bool, retries int) (string, error) {
+	if passwordFile != "" {
+		return readPasswordFile(passwordFile)
+	}
+	if pwd := os.Getenv(envVar); pwd != "" {
+		return pwd, nil
+	}
+
+	// Refuse to prompt when stdin is not a terminal so scripts fail fast instead of hanging.
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return "", fmt.Errorf("stdin is not a terminal; set %s to supply the password", envVar)
+	}
+
+	for retriesLeft := retries; ; retriesLeft-- {
+		password, err := promptPassword(fd, prompt)
+		if err != nil {
+			return "", err
+		}
+		if !confirm {
+			return password, nil
+		}
+		confirmation, err := promptPassword(fd, "Confirm password: ")
+		if err != nil {
+			return "", err
+		}
+		if confirmation == password {
+			return password, nil
+		}
+		if retriesLeft == 0 {
+			return "", errPasswordMismatch
+		}
+		errorf("%s Passwords do not match (%d attempts left).\n", markError, retriesLeft)
+	}
+}
+
+// readPasswordFlags is readPasswordFrom for commands that also take --password-stdin, which
+// replaces every other source when set.
+func readPasswordFlags(passwordStdin bool, passwordFile string, confirm bool, retries int) (string, error) {
+	if passwordStdin {
+		return readPasswordStdin()
+	}
+	return readPasswordFrom(passwordFile, passwordEnvVar, "Enter password: ", confirm, retries)
+}
+
+// checkPasswordStdin rejects --password-stdin alongside --password-file, or when stdin is a
+// terminal: the flag is for piped secrets, so it neither prompts nor waits for typed input.
+func checkPasswordStdin(passwordFile string) error {
+	if passwordFile != "" {
+		return errors.New("--password-stdin and --password-file cannot be used together")
+	}
+	if term.IsTerminal(int(os.Stdin.Fd())) {
+		return errors.New("--password-stdin needs the password piped to stdin, but stdin is a terminal; drop the flag to be prompted")
+	}
+	return nil
+}
+
+// readPasswordStdin returns the first line read from stdin with the line ending trimmed. The
+// rest of stdin is left unread. As with a password file, an empty password is rejected.
+func readPasswordStdin() (string, error) {
+	scanner := bufio.NewScanner(os.Stdin)
+	if !scanner.Scan() {
+		if err := scanner.Err(); err != nil {
+			return "", fmt.Errorf("could not read password from stdin: %v", err)
+		}
+		return "", errors.New("no password on stdin")
+	}
+	line := strings.TrimSuffix(scanner.Text(), "\r")
+	if line == "" {
+		return "", errors.New("password on stdin is empty")
+	}
+	return line, nil
+}
+
+// passwordPrompted reports whether readPasswordFrom would ask at the terminal, i.e. neither the
+// password file nor the environment variable supplies the password. Only then can a wrong
+// password be re-entered.
+func passwordPrompted(passwordFile, envVar string) bool {
+	return passwordFile == "" && os.Getenv(envVar) == ""
+}
+
+// promptPassword prints the prompt and reads a line from the terminal without echoing it.
+func promptPassword(fd int, prompt string) (string, error) {
+	fmt.Print(prompt)
+	pwdBytes, err := term.ReadPassword(fd)
+	fmt.Println() // Prints a newline character after password input.
+	if err != nil {
+		return "", err
+	}
+	return string(pwdBytes), nil
+}
+
+// confirmed prints the question and reports whether the answer read from stdin is yes. Anything
+// else, including an empty line or a read error, counts as no.
+func confirmed(question string) bool {
+	fmt.Print(question)
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && answer == "" {
+		fmt.Println()
+		return false
+	}
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true
+	}
+	return false
+}
+
+// readKeyfile returns the content of the --keyfile at path, or nil when path is empty. Every
+// byte counts, line endings included, and an empty file is rejected since it adds nothing.
+func readKeyfile(path string) ([]byte, error) {
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read key file: %v", err)
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("key file %s is empty", path)
+	}
+	return data, nil
+}
+
+// readPasswordFile returns the first line of the given file with the line ending trimmed.
+// An empty password is rejected rather than silently used.
+func readPasswordFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("could not read password file: %v", err)
+	}
+	line, _, _ := strings.Cut(string(data), "\n")
+	line = strings.TrimSuffix(line, "\r")
+	if line == "" {
+		return "", fmt.Errorf("password file %s is empty", path)
+	}
+	return line, nil
+}
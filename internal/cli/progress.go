@@ -0,0 +1,107 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/term"
+)
+
+// progressBarWidth is the number of cells in the drawn bar.
+const progressBarWidth = 30
+
+// progress draws a single self-updating "files processed / total" line for seal and unseal.
+// It is only active when stdout is a terminal and --quiet is not set; otherwise every method
+// is a no-op and the output stays clean for logs and pipes.
+type progress struct {
+	mu     sync.Mutex // Serializes report calls from parallel workers.
+	active bool
+	total  int
+	done   int
+	start  time.Time
+}
+
+// newProgress returns a progress line for total files, active only on an interactive stdout.
+func newProgress(total int) *progress {
+	active := !quiet && total > 0 && term.IsTerminal(int(os.Stdout.Fd()))
+	return &progress{active: active, total: total, start: time.Now()}
+}
+
+// countFiles counts the non-directory entries a walk of dir will visit, not descending into
+// directories for which skipDir reports true. Errors are left for the real walk to report.
+func countFiles(dir string, skipDir func(path string) bool) int {
+	total := 0
+	filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		if info.IsDir() {
+			if path != dir && skipDir(path) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		total++
+		return nil
+	})
+	return total
+}
+
+// wrap returns fn with the progress line cleared before it runs, so per-file messages print
+// on a clean line, and redrawn afterwards. Every file visited counts towards the total.
+func (p *progress) wrap(fn filepath.WalkFunc) filepath.WalkFunc {
+	if !p.active {
+		return fn
+	}
+	return func(path string, info os.FileInfo, err error) error {
+		p.clear()
+		walkErr := fn(path, info, err)
+		if err == nil && !info.IsDir() && p.done < p.total {
+			p.done++
+		}
+		p.draw()
+		return walkErr
+	}
+}
+
+// report runs print (which may be nil) with the progress line cleared and redraws it afterwards;
+// file counts one more file as processed. It is safe for concurrent use and prints even when the
+// line is inactive, so parallel workers never write over the line or each other.
+func (p *progress) report(file bool, print func()) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.clear()
+	if print != nil {
+		print()
+	}
+	if file && p.done < p.total {
+		p.done++
+	}
+	p.draw()
+}
+
+// draw renders the bar, counts, percentage and estimated time remaining.
+func (p *progress) draw() {
+	if !p.active {
+		return
+	}
+	filled := p.done * progressBarWidth / p.total
+	bar := strings.Repeat("=", filled) + strings.Repeat(" ", progressBarWidth-filled)
+	line := fmt.Sprintf("[%s] %d/%d (%d%%)", bar, p.done, p.total, p.done*100/p.total)
+	if p.done > 0 && p.done < p.total {
+		perFile := time.Since(p.start) / time.Duration(p.done)
+		line += fmt.Sprintf(" ETA %v", (perFile * time.Duration(p.total-p.done)).Round(time.Second))
+	}
+	fmt.Print("\r" + line + "\033[K")
+}
+
+// clear erases the progress line.
+func (p *progress) clear() {
+	if p.active {
+		fmt.Print("\r\033[K")
+	}
+}
@@ -0,0 +1,113 @@
+package cli
+
+import (
+	"aegis/internal/crypto"
+	"aegis/pkg/aegis"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// recoverPasswordFile holds the --password-file path; when set it takes precedence over other password sources.
+var recoverPasswordFile string
+
+// recoverKeyfile holds the --keyfile path, needed for a file sealed with seal --keyfile.
+var recoverKeyfile string
+
+// recoverExt holds --ext: the extension the recovered file is written with, replacing whatever the file records.
+var recoverExt string
+
+var recoverCmd = &cobra.Command{
+	Use:   "recover <file.aegis>",
+	Short: "Decrypt a single sealed file whose name cannot be restored",
+	Long: `Decrypt one sealed file, such as a file from an old format whose original extension is missing,
+and write the plaintext to stdout for inspection. With --ext, the plaintext is written next to the
+sealed file as <name><ext> instead, with the permissions and modification time the file records.
+The sealed file is never removed.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		path := args[0]
+		toFile := cmd.Flags().Changed("ext")
+		out := ""
+		if toFile {
+			ext := recoverExt
+			if ext != "" && !strings.HasPrefix(ext, ".") {
+				ext = "." + ext
+			}
+			if strings.ContainsAny(ext, `/\`) {
+				errorf("Error: invalid --ext %q\n", recoverExt)
+				os.Exit(exitFatal)
+			}
+			out = filepath.Join(filepath.Dir(path), strings.TrimSuffix(filepath.Base(path), ".aegis")+ext)
+			if _, err := os.Lstat(out); err == nil {
+				errorf("Error: '%s' already exists; not overwriting it\n", out)
+				os.Exit(exitFatal)
+			}
+		}
+
+		keyfile, err := readKeyfile(recoverKeyfile)
+		if err != nil {
+			errorf("Error: %v\n", err)
+			os.Exit(exitFatal)
+		}
+		password, err := readPassword(recoverPasswordFile, false)
+		if err != nil {
+			errorf("Error reading password: %v\n", err)
+			os.Exit(exitFatal)
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			errorf("Error: %v\n", err)
+			os.Exit(exitFatal)
+		}
+		defer f.Close()
+
+		payload, err := crypto.Open(f, filepath.Base(path), crypto.NewKeyfileKeyCache(password, keyfile))
+		if err == nil {
+			debugf("%s: format v%d, extension %q (recorded: %v), compressed=%v\n", path, payload.Version, payload.Ext, payload.HasExt, payload.Compressed)
+			if !payload.HasExt {
+				warnf("Warning: '%s' records no extension; the content may be incomplete or corrupted.\n", path)
+			}
+			if toFile {
+				err = aegis.WritePlaintext(out, payload.Content)
+			} else {
+				_, err = io.Copy(os.Stdout, payload.Content)
+			}
+		}
+		switch {
+		case errors.Is(err, crypto.ErrKeyfileRequired):
+			errorf("%s '%s' was sealed with a key file; supply it with --keyfile.\n", markError, path)
+			os.Exit(exitFatal)
+		case errors.Is(err, crypto.ErrDecryptFailed):
+			errorf("%s Decryption FAILED for '%s': %s.\n", markError, path, decryptFailure(err, keyfile))
+			os.Exit(exitPartial)
+		case err != nil:
+			errorf("%s Could not recover %s: %v\n", markFail, path, err)
+			os.Exit(exitPartial)
+		}
+		if !toFile {
+			return
+		}
+
+		if err := os.Chmod(out, payload.Mode); err != nil {
+			warnf("Warning: Failed to restore permissions on %s: %v\n", out, err)
+		}
+		if !payload.ModTime.IsZero() {
+			if err := os.Chtimes(out, payload.ModTime, payload.ModTime); err != nil {
+				warnf("Warning: Failed to restore modification time on %s: %v\n", out, err)
+			}
+		}
+		printColorf(colorGreen, "%s Recovered '%s' -> '%s'\n", markOK, path, out)
+	},
+}
+
+func init() {
+	recoverCmd.Flags().StringVar(&recoverPasswordFile, "password-file", "", "Read the password from the first line of this file")
+	recoverCmd.Flags().StringVar(&recoverKeyfile, "keyfile", "", "Key file given to seal --keyfile, if the file was sealed with one")
+	recoverCmd.Flags().StringVar(&recoverExt, "ext", "", "Write the plaintext next to the sealed file with this extension (e.g. .txt) instead of to stdout")
+	RootCmd.AddCommand(recoverCmd)
+}
@@ -0,0 +1,214 @@
+package cli
+
+import (
+	"aegis/internal/atomicfile"
+	"aegis/internal/crypto"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// rekeyPasswordFile holds the --password-file path for the current (old) password.
+var rekeyPasswordFile string
+
+// rekeyNewPasswordFile holds the --new-password-file path for the replacement password.
+var rekeyNewPasswordFile string
+
+// rekeyKeyfile holds the --keyfile path of files sealed with seal --keyfile; they are sealed again with it.
+var rekeyKeyfile string
+
+// rekeyPerFileSalt, when set via --per-file-salt, derives a fresh key for every file, as seal --per-file-salt does.
+var rekeyPerFileSalt bool
+
+// rekeyGroup identifies the files that share a new key: those that shared an old salt, cost
+// parameters and key file use. A tree sealed with --per-file-salt therefore keeps a salt per file.
+type rekeyGroup struct {
+	salt    string
+	kdf     crypto.KDFParams
+	keyfile bool
+}
+
+var rekeyCmd = &cobra.Command{
+	Use:   "rekey [directory]",
+	Short: "Change the password of sealed files",
+	Long: `Re-encrypt every .aegis file in a directory under a new password. Each file is decrypted chunk by chunk
+with the old password and sealed again with the new one, so plaintext never touches the disk.`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		dir := args[0]
+
+		if !quiet {
+			fmt.Printf("%s Rekeying sealed files in directory '%s'...\n", markRekey, dir)
+		}
+
+		keyfile, err := readKeyfile(rekeyKeyfile) // Read before the prompts, so a wrong path fails first.
+		if err != nil {
+			errorf("Error: %v\n", err)
+			os.Exit(exitFatal)
+		}
+		oldPassword, err := readPasswordFrom(rekeyPasswordFile, passwordEnvVar, "Old password: ", false, 0)
+		if err != nil {
+			errorf("Error reading old password: %v\n", err)
+			os.Exit(exitFatal)
+		}
+		newPassword, err := readPasswordFrom(rekeyNewPasswordFile, newPasswordEnvVar, "New password: ", true, 0)
+		if err != nil {
+			errorf("Error reading new password: %v\n", err)
+			os.Exit(exitFatal)
+		}
+
+		oldKeys := crypto.NewKeyfileKeyCache(oldPassword, keyfile)
+		newKeys := make(map[rekeyGroup]*crypto.Key) // One new key per old salt, so each file keeps its cost parameters and salt sharing.
+
+		sums, err := readChecksumManifest(dir, oldPassword) // Updated and re-signed under the new password after the walk.
+		if err != nil {
+			warnf("Warning: Could not read %s: %v. It will not be updated.\n", checksumManifestFile, err)
+		}
+
+		var filesRekeyed int  // Counter for files re-encrypted under the new password.
+		var filesWrongKey int // Counter for files that did not open with the old password.
+		var filesFailed int   // Counter for files that failed for any other reason.
+		walkErr := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			// Name and seal manifests are sealed files too and must follow the new password.
+			if info.IsDir() || !(strings.HasSuffix(path, ".aegis") || info.Name() == namesManifestFile || path == filepath.Join(dir, sealManifestFile)) {
+				return nil
+			}
+
+			// Only entries that still match are updated, so rekeying never hides earlier tampering.
+			var recorded, before manifestEntry
+			var listed bool
+			if sums != nil {
+				rel, _ := filepath.Rel(dir, path)
+				if recorded, listed = sums.Files[filepath.ToSlash(rel)]; listed {
+					before, _ = hashFile(path)
+				}
+			}
+
+			if err := rekeyFile(path, info, oldKeys, newPassword, keyfile, newKeys); err != nil {
+				switch {
+				case errors.Is(err, crypto.ErrKeyfileRequired):
+					errorf("%s '%s' was sealed with a key file; supply it with --keyfile. Left unchanged.\n", markError, path)
+					filesWrongKey++
+				case errors.Is(err, crypto.ErrCorrupted): // The old password was right; the file itself is damaged.
+					errorf("%s Failed to rekey '%s': %s. Left unchanged.\n", markError, path, decryptFailure(err, keyfile))
+					filesFailed++
+				case errors.Is(err, crypto.ErrDecryptFailed):
+					errorf("%s Old password check FAILED for '%s': %s. Left unchanged.\n", markError, path, decryptFailure(err, keyfile))
+					filesWrongKey++
+				default:
+					errorf("%s Failed to rekey '%s': %v. Left unchanged.\n", markFail, path, err)
+					filesFailed++
+				}
+				return nil
+			}
+			if listed {
+				if before.Size == recorded.Size && before.SHA256 == recorded.SHA256 {
+					if err := recordChecksum(sums, dir, path); err != nil {
+						warnf("Warning: Could not update %s in %s: %v\n", path, checksumManifestFile, err)
+					}
+				} else {
+					warnf("Warning: '%s' did not match %s before rekeying; its entry is left as it was.\n", path, checksumManifestFile)
+				}
+			}
+			filesRekeyed++
+			if !quiet {
+				fmt.Printf("%s Rekeyed '%s'\n", markOK, path)
+			}
+			return nil
+		})
+		if walkErr != nil {
+			errorf("\n\n%s Fatal Error during rekeying: %v\n", markFatal, walkErr)
+			os.Exit(exitFatal)
+		}
+		if sums != nil {
+			if err := saveChecksumManifest(dir, sums, newPassword, sums.KDF); err != nil {
+				errorf("%s Failed to update %s: %v\n", markFail, checksumManifestFile, err)
+				filesFailed++
+			}
+		}
+
+		fmt.Printf("\n%s Rekeying complete for directory '%s'.\n", markDone, dir)
+		fmt.Printf("   Successfully rekeyed %d files.\n", filesRekeyed)
+		if filesWrongKey > 0 {
+			fmt.Printf("   %d files failed the old-password check.\n", filesWrongKey)
+		}
+		if filesFailed > 0 {
+			fmt.Printf("   Failed to rekey %d files.\n", filesFailed)
+		}
+		if filesWrongKey > 0 || filesFailed > 0 {
+			os.Exit(exitPartial)
+		}
+	},
+}
+
+// rekeyFile decrypts path with the old password and atomically replaces it with a copy sealed
+// under the new password, streaming one chunk at a time, so a failure leaves the original untouched.
+// A file sealed with a key file is sealed with keyfile again, so it stays two-factor.
+func rekeyFile(path string, info os.FileInfo, oldKeys *crypto.KeyCache, newPassword string, keyfile []byte, newKeys map[rekeyGroup]*crypto.Key) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	payload, err := crypto.Open(f, filepath.Base(path), oldKeys)
+	if err != nil {
+		return err
+	}
+	if !payload.HasExt {
+		return fmt.Errorf("missing extension terminator")
+	}
+
+	group := rekeyGroup{salt: string(payload.Salt), kdf: payload.KDF, keyfile: payload.Keyfile}
+	key := newKeys[group]
+	if key == nil {
+		var factor []byte // Files sealed without a key file stay password-only.
+		if payload.Keyfile {
+			factor = keyfile
+		}
+		if key, err = crypto.NewKeyfileKey(newPassword, factor, payload.KDF); err != nil {
+			return err
+		}
+		if !rekeyPerFileSalt {
+			newKeys[group] = key
+		}
+	}
+
+	modTime := payload.ModTime
+	if modTime.IsZero() { // Formats before v3 carry no timestamp; keep the sealed file's own.
+		modTime = info.ModTime()
+	}
+	meta := crypto.Metadata{Mode: payload.Mode, ModTime: modTime, Ext: payload.Ext, Name: payload.Name}
+
+	// The stored content is re-sealed chunk by chunk as it authenticates, still compressed if it
+	// was. The temp file only replaces path once the whole stream has been read, so a chunk that
+	// fails leaves the original untouched.
+	stored, size := payload.Stored(info.Size())
+	return atomicfile.Write(path, 0600, func(w io.Writer) error {
+		if err := crypto.Seal(w, filepath.Base(path), meta, stored, size, payload.Compressed, key); err != nil {
+			return err
+		}
+		if n, err := io.Copy(io.Discard, stored); err != nil { // Reading to EOF authenticates the last chunk.
+			return err
+		} else if n > 0 {
+			return fmt.Errorf("%d bytes of content beyond the expected %d", n, size)
+		}
+		return nil
+	})
+}
+
+func init() {
+	rekeyCmd.Flags().StringVar(&rekeyPasswordFile, "password-file", "", "Read the current password from the first line of this file")
+	rekeyCmd.Flags().StringVar(&rekeyNewPasswordFile, "new-password-file", "", "Read the new password from the first line of this file (or set "+newPasswordEnvVar+")")
+	rekeyCmd.Flags().StringVar(&rekeyKeyfile, "keyfile", "", "Key file of files sealed with seal --keyfile; they are sealed again with the same key file")
+	rekeyCmd.Flags().BoolVar(&rekeyPerFileSalt, "per-file-salt", false, "Derive a separate key for every file, even for files that shared a salt (slower; one scrypt run per file)")
+	RootCmd.AddCommand(rekeyCmd)
+}
@@ -7,6 +7,33 @@ import (
 	"github.com/spf13/cobra"
 )
 
+// Build information, injected at build time:
+//
+//	go build -ldflags "-X aegis/internal/cli.Version=v1.2.0 -X aegis/internal/cli.Commit=$(git rev-parse --short HEAD) -X aegis/internal/cli.BuildDate=$(date -u +%Y-%m-%dT%H:%M:%SZ)" ./cmd/aegis
+var (
+	Version   = "dev"
+	Commit    = "none"
+	BuildDate = "unknown"
+)
+
+// Process exit codes shared by the commands that process a directory.
+const (
+	exitFatal   = 1 // The command could not run: bad flags, unreadable password, or a failed directory walk.
+	exitPartial = 2 // The command ran to completion but one or more files failed.
+
+	exitInterrupted = 130 // Stopped by Ctrl+C or SIGTERM between files (128 + SIGINT, as shells report it).
+)
+
+// showVersion is set by the persistent --version flag.
+var showVersion bool
+
+// quiet is set by the persistent --quiet flag: per-file progress lines are suppressed and only
+// errors (on stderr) and the final summary are printed.
+var quiet bool
+
+// verbose is set by the persistent --verbose flag: every skipped item is reported with its reason.
+var verbose bool
+
 var RootCmd = &cobra.Command{
 	Use:   "aegis",
 	Short: "Aegis - A secure file encryption tool",
@@ -21,15 +48,45 @@ var RootCmd = &cobra.Command{
   # View help for a specific command
   aegis seal --help`,
 
+	// --version is accepted by every command and short-circuits it.
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		if err := setLogLevel(logLevelFlag); err != nil {
+			errorf("Error: %v\n", err)
+			os.Exit(exitFatal)
+		}
+		setupColor()
+		setupEmoji()
+		if showVersion {
+			printVersion()
+			os.Exit(0)
+		}
+	},
+
 	Run: func(cmd *cobra.Command, args []string) {
 		// Show help when no subcommand is provided
 		cmd.Help()
 	},
 }
 
+func init() {
+	RootCmd.PersistentFlags().BoolVar(&showVersion, "version", false, "Print version information and exit")
+	RootCmd.PersistentFlags().BoolVarP(&quiet, "quiet", "q", false, "Only print errors and the final summary")
+	RootCmd.PersistentFlags().StringVar(&logLevelFlag, "log-level", "info", "Lowest severity of diagnostics written to stderr: debug, info, warn or error")
+	RootCmd.PersistentFlags().BoolVar(&noColor, "no-color", false, "Disable colored output (also disabled by NO_COLOR and when not writing to a terminal)")
+	RootCmd.PersistentFlags().BoolVar(&noEmoji, "no-emoji", false, "Print plain ASCII tags such as [OK] and [FAIL] instead of emoji (also enabled by "+noEmojiEnvVar+")")
+	RootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "Report why each skipped item was skipped (ignored with --quiet)")
+}
+
+// verbosef prints a detail line only when --verbose is set (and --quiet is not).
+func verbosef(format string, a ...any) {
+	if verbose && !quiet {
+		fmt.Printf(format, a...)
+	}
+}
+
 func Execute() error {
 	if err := RootCmd.Execute(); err != nil {
-		fmt.Fprintln(os.Stderr, err)
+		errorf("%v\n", err)
 		return err
 	}
 	return nil
@@ -1,21 +1,114 @@
 package cli
 
 import (
-	"crypto/aes"    // Standard library for AES encryption.
-	"crypto/cipher" // Standard library for cipher modes (GCM).
-	"crypto/rand"   // Source for cryptographically secure random numbers (salt, nonce).
+	"aegis/internal/atomicfile"
+	"aegis/internal/crypto"
+	"aegis/pkg/aegis"
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
 	"strings"
-
-	"golang.org/x/crypto/scrypt" // Industry-standard package for Key Derivation Function (KDF).
-	"golang.org/x/term"          // Used to read password without echoing to the console.
+	"time"
 
 	"github.com/spf13/cobra"
+	"golang.org/x/term"
 )
 
+// sealKeep, when set via --keep, retains the original plaintext files after sealing.
+var sealKeep bool
+
+// sealShred, when set via --shred, overwrites each original with random data before deleting it.
+var sealShred bool
+
+// sealShredPasses holds --shred-passes: how many times --shred overwrites each original.
+var sealShredPasses int
+
+// sealWriteManifest, when set via --manifest, records every sealed file in an encrypted aegis.manifest.
+var sealWriteManifest bool
+
+// sealChecksumManifest, when set via --checksum-manifest, records the hash of every sealed file in a signed aegis.checksums.
+var sealChecksumManifest bool
+
+// sealPasswordFile holds the --password-file path; when set it takes precedence over other password sources.
+var sealPasswordFile string
+
+// sealPasswordStdin, when set via --password-stdin, reads the password from the first line piped to stdin.
+var sealPasswordStdin bool
+
+// sealPromptRetries holds --prompt-retries: how many times a mismatched confirmation may be re-entered at the prompt.
+var sealPromptRetries int
+
+// sealKeyfile holds the --keyfile path; when set, files need both the password and this file to be unsealed.
+var sealKeyfile string
+
+// sealDryRun, when set via --dry-run, reports what would be sealed without writing or deleting anything.
+var sealDryRun bool
+
+// sealKDF holds the scrypt cost parameters set via --scrypt-n, --scrypt-r and --scrypt-p.
+var sealKDF = crypto.DefaultKDFParams
+
+// sealAllowWeakKDF, when set via --allow-weak-kdf, seals with scrypt parameters below crypto.MinKDFParams.
+var sealAllowWeakKDF bool
+
+// sealPerFileSalt, when set via --per-file-salt, derives a fresh key for every file instead of once per session.
+var sealPerFileSalt bool
+
+// sealOutput, when set via --output, mirrors the sealed tree into this directory and leaves the source untouched.
+var sealOutput string
+
+// sealHideNames, when set via --hide-names, replaces sealed file names with random tokens and
+// records the original names in each directory's encrypted name manifest.
+var sealHideNames bool
+
+// sealCompress, when set via --compress, deflates file content before encryption.
+var sealCompress bool
+
+// sealExcludes holds the repeatable --exclude glob patterns, applied in addition to the defaults.
+var sealExcludes []string
+
+// sealNoDefaultExcludes, when set via --no-default-excludes, disables the built-in exclude list.
+var sealNoDefaultExcludes bool
+
+// sealExcludeExts holds the repeatable --exclude-ext extensions; matching files are left unsealed.
+var sealExcludeExts []string
+
+// sealOnlyExts holds the repeatable --only-ext extensions; when set, only matching files are sealed.
+var sealOnlyExts []string
+
+// sealMaxFileSize and sealMinFileSize hold --max-file-size and --min-file-size (e.g. "100MB");
+// files outside the range are left unsealed. Empty means no limit.
+var sealMaxFileSize, sealMinFileSize string
+
+// sealIncludeEmptyDirs, when set via --include-empty-dirs, records every directory in the seal manifest so unseal can recreate empty ones.
+var sealIncludeEmptyDirs bool
+
+// sealRecursive holds --recursive; when false, only the files directly in the directory are sealed.
+var sealRecursive bool
+
+// sealMaxDepth holds --max-depth: how many directory levels below the root are sealed; 0 means no limit.
+var sealMaxDepth int
+
+// sealFollowSymlinks, when set via --follow-symlinks, seals the files and directories symlinks point to instead of skipping them.
+var sealFollowSymlinks bool
+
+// sealYes, when set via --yes, skips the confirmation asked at a terminal before originals are deleted.
+var sealYes bool
+
+// sealForce, when set via --force, seals git repositories found in the directory instead of refusing to.
+var sealForce bool
+
+// sealSkipHidden, when set via --skip-hidden, leaves dotfiles and dot-directories unsealed (except likely secrets).
+var sealSkipHidden bool
+
+// sealResume, when set via --resume, skips files whose output an earlier, interrupted run already sealed.
+var sealResume bool
+
+// sealJSON, when set via --json, prints the final summary as one JSON object on stdout and moves all other output to stderr.
+var sealJSON bool
+
 // var sealCmd defines the structure and metadata for the 'aegis seal' command.
 var sealCmd = &cobra.Command{
 	Use:   "seal [directory]",                                                              // Defines the command usage syntax.
@@ -23,139 +116,709 @@ var sealCmd = &cobra.Command{
 	Long:  `Seal (encrypt) a directory and all its contents using a password-derived key.`, // A detailed description.
 	Args:  cobra.MinimumNArgs(1),                                                           // Ensures at least one argument (the directory path) is provided.
 	Run: func(cmd *cobra.Command, args []string) { // The function executed when 'aegis seal' is run.
-		dir := args[0] // Retrieves the directory path provided as the first argument.
+		dir := args[0]       // Retrieves the directory path provided as the first argument.
+		if dir == stdioArg { // Streams stdin to stdout; no directory is walked and nothing is removed.
+			sealStdio(cmd)
+			return
+		}
+		var jsonOut io.Writer // The real stdout under --json, left to the summary object alone.
+		if sealJSON {
+			jsonOut = jsonStdout()
+		}
+		if sealPasswordStdin {
+			if err := checkPasswordStdin(sealPasswordFile); err != nil {
+				errorf("Error: %v\n", err)
+				os.Exit(exitFatal)
+			}
+		}
+		if sealShred && (sealKeep || sealOutput != "") { // Only originals that are deleted get shredded.
+			errorf("Error: --shred cannot be used with --keep or --output, which delete nothing\n")
+			os.Exit(exitFatal)
+		}
+		if sealShredPasses < 1 {
+			errorf("Error: --shred-passes must be at least 1\n")
+			os.Exit(exitFatal)
+		}
+		if sealPromptRetries < 0 {
+			errorf("Error: --prompt-retries cannot be negative\n")
+			os.Exit(exitFatal)
+		}
+		if sealMaxDepth < 0 {
+			errorf("Error: --max-depth cannot be negative\n")
+			os.Exit(exitFatal)
+		}
+		if sealResume && sealDryRun { // Telling finished outputs apart takes the password, which a dry run does not ask for.
+			errorf("Error: --resume cannot be used with --dry-run\n")
+			os.Exit(exitFatal)
+		}
+		if err := checkSealKDF(); err != nil { // Rejects unusable or weak cost parameters before the prompt.
+			errorf("Error: %v\n", err)
+			os.Exit(exitFatal)
+		}
+
+		keyfile, err := readKeyfile(sealKeyfile) // Read before the prompt, so a wrong path fails before the password is typed.
+		if err != nil {
+			errorf("Error: %v\n", err)
+			os.Exit(exitFatal)
+		}
+		var password string // Password used for key derivation (not needed for a dry run).
+		if sealDryRun {
+			fmt.Printf("%s Dry run: showing what would be sealed in '%s'...\n", markDryRun, dir)
+		} else if !quiet {
+			fmt.Printf("%s Securing directory '%s'...\n", markSeal, dir)
+		}
+		if !sealDryRun {
+			// Reads password from --password-stdin, --password-file, AEGIS_PASSWORD, or the terminal (confirmed twice when interactive).
+			pwd, err := readPasswordFlags(sealPasswordStdin, sealPasswordFile, true, sealPromptRetries)
+			if err != nil { // Checks if reading the password failed.
+				// Prints error to standard error stream (os.Stderr) and exits with exitFatal
+				errorf("Error reading password: %v\n", err) // Prints error to the standard error stream.
+				os.Exit(exitFatal)                          // Nothing was processed, so this is a fatal error.
+			}
+			password = pwd
+		}
+
+		started := time.Now() // Summary timing; starts after the password prompt so typing is not counted.
 
-		fmt.Printf("🔒 Securing directory '%s'...\n", dir)
-		fmt.Print("Enter password: ")
+		// Shared-key mode (default): one salt and one scrypt run for the whole session. Per-file
+		// nonces keep every encryption unique; the salt is still written to each header so files stay self-contained.
+		var sessionKey *crypto.Key // Key and salt shared by every file sealed in this run.
+		if !sealDryRun && !sealPerFileSalt {
+			start := time.Now()
+			key, err := crypto.NewKeyfileKey(password, keyfile, sealKDF)
+			if err != nil {
+				errorf("Error: %v\n", err)
+				os.Exit(exitFatal)
+			}
+			debugf("derived session key (scrypt N=%d, r=%d, p=%d) in %v\n", sealKDF.N, sealKDF.R, sealKDF.P, time.Since(start).Round(time.Millisecond))
+			sessionKey = key
+		}
+
+		retainOriginals := sealKeep || sealOutput != "" // Writing to a separate output tree never deletes the source.
+		var outputAbs string                            // Absolute output root, used to avoid sealing our own output.
+		sealedRoot := dir                               // Root of the sealed tree, where the manifests are written.
+		if sealOutput != "" {
+			outputAbs, _ = filepath.Abs(sealOutput)
+			sealedRoot = sealOutput
+		}
+
+		var checksums *checksumManifest // Sealed relative path -> size and hash of the sealed file (--checksum-manifest).
+		if sealChecksumManifest && !sealDryRun {
+			m, err := readChecksumManifest(sealedRoot, password) // Files sealed by earlier runs stay listed.
+			if err != nil {
+				errorf("Error: could not read the existing %s: %v\n", checksumManifestFile, err)
+				os.Exit(exitFatal)
+			}
+			if m == nil {
+				m = &checksumManifest{Files: make(map[string]manifestEntry)}
+			}
+			checksums = m
+		}
 
-		// Reads password from STDIN without showing input.
-		pwdBytes, err := term.ReadPassword(int(os.Stdin.Fd()))
-		if err != nil { // Checks if reading the password failed.
-			// Prints error to standard error stream (os.Stderr) and exits cleanly
-			fmt.Fprintf(os.Stderr, "Error reading password: %v\n", err) // Prints error to the standard error stream.
-			return                                                      // Exit Run function immediately
+		// Exclusion logic: built-in defaults plus any --exclude patterns.
+		var excludeList []string // Patterns of items to skip.
+		if !sealNoDefaultExcludes {
+			excludeList = append(excludeList, defaultExcludes...)
+		}
+		excludeList = append(excludeList, sealExcludes...)
+		excludes, err := newExcludeMatcher(excludeList)
+		if err != nil { // Rejects malformed patterns before touching any file.
+			errorf("Error: %v\n", err)
+			os.Exit(exitFatal)
+		}
+		exts, err := newExtFilter(sealExcludeExts, sealOnlyExts) // Extension-level selection on top of the patterns.
+		if err != nil {
+			errorf("Error: %v\n", err)
+			os.Exit(exitFatal)
+		}
+		var maxSize, minSize int64 // Size range of the files to seal; maxSize 0 means no upper limit.
+		for _, limit := range []struct {
+			flag, value string
+			size        *int64
+		}{{"--max-file-size", sealMaxFileSize, &maxSize}, {"--min-file-size", sealMinFileSize, &minSize}} {
+			if limit.value == "" {
+				continue
+			}
+			if *limit.size, err = parseByteSize(limit.value); err != nil {
+				errorf("Error: %s: %v\n", limit.flag, err)
+				os.Exit(exitFatal)
+			}
+		}
+		if maxSize > 0 && minSize > maxSize {
+			errorf("Error: --min-file-size is larger than --max-file-size\n")
+			os.Exit(exitFatal)
+		}
+		hasIgnoreFile, err := excludes.loadIgnoreFile(dir) // Shared, version-controlled patterns from <dir>/.aegisignore.
+		if err != nil {
+			errorf("Error: %v\n", err)
+			os.Exit(exitFatal)
+		}
+		if hasIgnoreFile && !quiet {
+			fmt.Printf("   Using exclude patterns from %s\n", filepath.Join(dir, ignoreFileName))
 		}
-		password := string(pwdBytes) // Converts the secure byte slice password into a string.
-		fmt.Println()                // Prints a newline character after password input.
 
-		// Placeholder for exclusion logic
-		excludeList := []string{".git", "vendor", "node_modules", "target"} // Default list of items to skip.
-		excludeSet := make(map[string]bool)                                 // Creates a map for fast lookup of exclusions.
-		for _, item := range excludeList {                                  // Iterates through the list.
-			excludeSet[item] = true // Populates the map.
+		hiddenNames := make(map[string]nameManifest)    // Output directory -> token -> original name (--hide-names).
+		manifestFiles := make(map[string]manifestEntry) // Original relative path -> size and hash (--manifest).
+		manifestLinks := make(map[string]string)        // Original relative path -> symlink target, for followed links (--manifest).
+		var manifestDirs []string                       // Relative directory paths (--include-empty-dirs).
+		visitedDirs := make(map[string]bool)            // Resolved directories already walked (--follow-symlinks), so link loops end.
+		sealedFrom := make(map[string]string)           // Output path -> source sealed into it by this run.
+		var existingKeys *crypto.KeyCache               // Opens existing outputs to tell an earlier seal of the same file from a collision.
+		var self os.FileInfo                            // The running executable, never sealed; nil if it cannot be found.
+		if exe, err := os.Executable(); err == nil {
+			self, _ = os.Stat(exe)
+		}
+		if !sealDryRun {
+			existingKeys = crypto.NewKeyfileKeyCache(password, keyfile)
+		}
+		resumeTokens := make(map[string]map[string]string) // --resume --hide-names: output directory -> original name -> token.
+		resumedToken := func(dirPath, name string) string {
+			tokens, ok := resumeTokens[dirPath]
+			if !ok {
+				names, _ := readNameManifest(dirPath, existingKeys) // Unreadable or missing: every file gets a new token.
+				tokens = make(map[string]string, len(names))
+				for token, original := range names {
+					tokens[original] = token
+				}
+				resumeTokens[dirPath] = tokens
+			}
+			return tokens[name]
 		}
 
-		var filesSealed int  // Counter for successfully sealed files.
-		var filesSkipped int // Counter for skipped files.
+		var filesSealed int      // Counter for successfully sealed files.
+		var filesSkipped int     // Counter for skipped files.
+		var filesResumed int     // Counter for files already sealed by an earlier run (--resume).
+		var failures failureList // Files that could not be sealed, and why, for the closing report.
+		var bytesSealed int64    // Plaintext bytes of the files sealed (or, in a dry run, that would be).
 		// walkErr captures any fatal error from the directory walk.
-		walkErr := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		// dirSkipReason reports why the walk does not descend into the directory at path, or "" if it does.
+		dirSkipReason := func(path string) string {
+			rel, _ := filepath.Rel(dir, path)
+			switch {
+			case path == dir: // The root itself is never skipped.
+				return ""
+			case !sealRecursive:
+				return "subdirectory, --recursive=false"
+			case beyondMaxDepth(rel, sealMaxDepth):
+				return "deeper than --max-depth"
+			case excludes.match(rel, true):
+				return "excluded directory"
+			case sealSkipHidden && isHiddenSkipped(filepath.Base(path), true):
+				return "hidden directory"
+			case isWatchSessionDir(path):
+				return "watch session logs"
+			}
+			if outputAbs != "" { // Never descends into the output tree when it lives inside the source.
+				if abs, _ := filepath.Abs(path); abs == outputAbs {
+					return "output directory"
+				}
+			}
+			return ""
+		}
+
+		// A sealed .git leaves the repository broken, so reaching one (only possible with
+		// --no-default-excludes) needs --force unless nothing is changed in place.
+		if repos := findGitRepos(dir, func(path string) bool { return dirSkipReason(path) != "" }); len(repos) > 0 {
+			for _, repo := range repos {
+				warnf("%s WARNING: '%s' is git metadata; sealing it leaves the repository unusable until it is unsealed.\n", markWarn, repo)
+			}
+			if !sealDryRun && sealOutput == "" && !sealForce {
+				errorf("Error: refusing to seal git repositories; exclude them (drop --no-default-excludes or add --exclude .git) or pass --force\n")
+				os.Exit(exitFatal)
+			}
+		}
+
+		// Progress line (interactive terminals only): the files to visit are counted up front.
+		total := 0 // Dry runs do no crypto work and need no progress line.
+		if !sealDryRun {
+			total = countFiles(dir, func(path string) bool { return dirSkipReason(path) != "" })
+		}
+		// Deleting originals is hard to undo, so an interactive run asks first. Scripts (stdin not a
+		// terminal) and --yes go ahead; --keep and --output delete nothing and are never asked.
+		if total > 0 && !retainOriginals && !sealYes && term.IsTerminal(int(os.Stdin.Fd())) {
+			fmt.Printf("%s About to seal up to %d files in '%s' and delete the originals.\n", markWarn, total, dir)
+			if !confirmed("Proceed? [y/N] ") {
+				fmt.Println("Aborted; nothing was sealed.")
+				os.Exit(exitFatal)
+			}
+		}
+		bar := newProgress(total)
+
+		ctx, release := interruptContext() // Ctrl+C stops the walk between files.
+		defer release()
+
+		var walkFn filepath.WalkFunc // Declared before it is assigned so linked directories can be walked with it.
+		walkFn = bar.wrap(func(path string, info os.FileInfo, err error) error {
 			// If the walk encounters an error (like non-existent directory),
 			// we must return the error itself to the main walkErr variable and trigger the Fatal Error block at the end.
 			if err != nil {
 				return err // Returns the error, stopping the walk and populating walkErr.
 			}
+			if err := ctx.Err(); err != nil { // Interrupted: the previous file is complete, this one is not started.
+				return err
+			}
 
-			// Exclusion and Symlink checks (Filtering Logic)
 			if info.IsDir() { // Checks if the current path is a directory.
-				if excludeSet[info.Name()] { // Checks if the directory name is in the exclusion list.
-					fmt.Printf("   Skipping excluded directory: %s\n", info.Name())
+				if reason := dirSkipReason(path); reason != "" { // Excluded, hidden, or the output tree.
+					if !quiet {
+						fmt.Printf("   Skipping (%s): %s\n", reason, path)
+					}
 					return filepath.SkipDir // Skip this directory and its contents
 				}
-				if path == dir {
-					return nil
+				if sealFollowSymlinks { // With links followed, the same directory can be reached twice or in a loop.
+					if real, err := filepath.EvalSymlinks(path); err == nil {
+						if visitedDirs[real] {
+							if !quiet {
+								fmt.Printf("   Skipping (directory already visited through a symlink): %s\n", path)
+							}
+							return filepath.SkipDir
+						}
+						visitedDirs[real] = true
+					}
+				}
+				if sealIncludeEmptyDirs && path != dir { // Sealing only writes files, so the layout is kept separately.
+					rel, _ := filepath.Rel(dir, path)
+					manifestDirs = append(manifestDirs, filepath.ToSlash(rel))
 				}
 				return nil // Continues traversal into subdirectories.
 			}
 
+			// Exclusion and Symlink checks (Filtering Logic)
+			rel, _ := filepath.Rel(dir, path)      // Path relative to the walk root, used for pattern matching.
+			excluded := excludes.match(rel, false) // Files only; the root is a directory and never excluded.
+			hidden := sealSkipHidden && isHiddenSkipped(info.Name(), false)
+
+			if excluded { // Checks if the file matches an exclude pattern.
+				if sealDryRun {
+					fmt.Printf("   Would skip (excluded): %s\n", path)
+				} else {
+					verbosef("   Skipping (excluded): %s\n", path)
+				}
+				filesSkipped++
+				return nil
+			}
+
+			linkTarget := ""                         // Set when a followed symlink is sealed in place of the file it points to.
 			if (info.Mode() & os.ModeSymlink) != 0 { // Checks if the file is a symbolic link.
-				fmt.Printf("   Skipping symbolic link: %s\n", path)
+				if !sealFollowSymlinks {
+					if !quiet {
+						fmt.Printf("   Skipping (symlink): %s\n", path)
+					}
+					filesSkipped++
+					return nil // Skips symlinks for security/robustness.
+				}
+				resolved, err := os.Stat(path) // Follows the whole chain; fails for dangling links and link cycles.
+				if err != nil {
+					errorf("%s Cannot follow symlink %s: %v. Skipping.\n", markFail, path, err)
+					failures.add(path, "cannot follow symlink: %v", err)
+					return nil
+				}
+				if resolved.IsDir() { // Walks the linked directory under the link's own path.
+					return filepath.Walk(path+string(filepath.Separator), walkFn)
+				}
+				if !resolved.Mode().IsRegular() {
+					if !quiet {
+						fmt.Printf("   Skipping (symlink to a special file): %s\n", path)
+					}
+					filesSkipped++
+					return nil
+				}
+				if linkTarget, err = os.Readlink(path); err != nil {
+					linkTarget = "?" // Only used for reporting; the content was already resolved above.
+				}
+				info = resolved // Size, mode and modification time come from the target.
+			}
+
+			if self != nil && os.SameFile(info, self) { // Sealing the binary mid-run would leave no aegis to unseal with.
+				if !quiet {
+					fmt.Printf("   Skipping (the running aegis executable): %s\n", path)
+				}
+				filesSkipped++
+				return nil
+			}
+
+			if info.Name() == namesManifestFile { // Name manifests are already encrypted.
+				verbosef("   Skipping (name manifest): %s\n", path)
+				return nil
+			}
+
+			if rel == sealManifestFile { // The manifest is already encrypted.
+				verbosef("   Skipping (manifest): %s\n", path)
+				return nil
+			}
+
+			if rel == checksumManifestFile { // Signed rather than sealed, and rewritten by --checksum-manifest.
+				verbosef("   Skipping (checksum manifest): %s\n", path)
+				return nil
+			}
+
+			if rel == ignoreFileName { // The ignore file stays readable so later runs apply the same patterns.
+				verbosef("   Skipping (ignore file): %s\n", path)
+				return nil
+			}
+
+			if atomicfile.IsTemp(info.Name()) { // Leftover from an interrupted write; never seal it.
+				verbosef("   Skipping (temporary file from an interrupted write): %s\n", path)
+				return nil
+			}
+
+			if hidden { // Bookkeeping dotfiles were handled above; --skip-hidden leaves other dotfiles alone.
+				if sealDryRun {
+					fmt.Printf("   Would skip (hidden): %s\n", path)
+				} else {
+					verbosef("   Skipping (hidden): %s\n", path)
+				}
 				filesSkipped++
-				return nil // Skips symlinks for security/robustness.
+				return nil
 			}
 
 			if strings.HasSuffix(path, ".aegis") { // Checks if the file is already sealed.
+				if sealDryRun {
+					fmt.Printf("   Would skip (already sealed): %s\n", path)
+				} else {
+					verbosef("   Skipping (already sealed): %s\n", path)
+				}
 				filesSkipped++
 				return nil // Skips already sealed files.
 			}
 
-			plaintext, err := os.ReadFile(path) // Reads the entire file content into memory.
-			if err != nil {                     // Checks for file read errors (e.g., permissions).
-				fmt.Printf("❌ Could not read file %s: %v. Skipping.\n", path, err)
-				return nil // Skip this file, but continue the walk
+			if skip, reason := exts.skip(path); skip { // --exclude-ext / --only-ext.
+				if sealDryRun {
+					fmt.Printf("   Would skip (%s): %s\n", reason, path)
+				} else {
+					verbosef("   Skipping (%s): %s\n", reason, path)
+				}
+				filesSkipped++
+				return nil
 			}
 
-			// Crypto Setup
-			// 1. Salt Generation: Unique, 16-byte random salt for every file.
-			salt := make([]byte, 16)                   // Creates a 16-byte buffer for the unique salt.
-			if _, err := rand.Read(salt); err != nil { // Fills the salt buffer with CSPRNG data.
-				return fmt.Errorf("failed to generate salt for %s: %v", path, err) // Returns error for fatal crypto failure.
+			sizeReason := "" // --max-file-size / --min-file-size.
+			switch {
+			case maxSize > 0 && info.Size() > maxSize:
+				sizeReason = fmt.Sprintf("larger than %s", formatSize(maxSize))
+			case info.Size() < minSize:
+				sizeReason = fmt.Sprintf("smaller than %s", formatSize(minSize))
 			}
-			// 2. Key Derivation: Scrypt generates a strong 32-byte key (AES-256) from the password + salt.
-			key, err := scrypt.Key([]byte(password), salt, 1<<15, 8, 1, 32)
-			if err != nil {
-				return fmt.Errorf("failed to derive key for %s: %v", path, err)
+			if sizeReason != "" {
+				if sealDryRun {
+					fmt.Printf("   Would skip (%s): %s\n", sizeReason, path)
+				} else {
+					verbosef("   Skipping (%s): %s\n", sizeReason, path)
+				}
+				filesSkipped++
+				return nil
 			}
-			// 3. GCM Setup: Initializes AES in Galois/Counter Mode (GCM) for authenticated encryption.
-			block, err := aes.NewCipher(key) // Creates the AES block cipher instance.
-			if err != nil {
-				return fmt.Errorf("failed to create cipher block for %s: %v", path, err)
+
+			// Construct the clean output filename (remove original extension, add .aegis)
+			baseName, _ := aegis.SplitExt(filepath.Base(path)) // Removes old extension from filename.
+			dirPath := filepath.Dir(path)                      // Gets the directory part of the path.
+			if sealOutput != "" {                              // Mirrors the relative location under the output root.
+				rel, err := filepath.Rel(dir, dirPath)
+				if err != nil {
+					return fmt.Errorf("failed to resolve relative path for %s: %v", path, err)
+				}
+				dirPath = filepath.Join(sealOutput, rel)
+			}
+			out := filepath.Join(dirPath, baseName+".aegis") // Joins path with the new masked filename.
+			if sealHideNames {                               // Replaces the base name with a random token.
+				token, err := newHiddenName()
+				if err != nil {
+					return err
+				}
+				if sealResume { // Reuses the token an earlier run gave this file, found in the name manifest.
+					if earlier := resumedToken(dirPath, filepath.Base(path)); earlier != "" {
+						token = earlier
+					}
+				}
+				out = filepath.Join(dirPath, token)
 			}
 
-			gcm, err := cipher.NewGCM(block) // Sets up Galois/Counter Mode (GCM) for authenticated encryption.
-			if err != nil {
-				return fmt.Errorf("failed to create GCM for %s: %v", path, err)
+			// Collisions: notes.txt and notes.md both map to notes.aegis; the second must not replace the first.
+			if first, ok := sealedFrom[out]; ok {
+				errorf("%s Cannot seal %s: '%s' is already the sealed form of %s (same name, different extension). Skipping.\n", markFail, path, out, first)
+				failures.add(path, "'%s' is already the sealed form of %s", out, first)
+				return nil
 			}
-			// 4. Nonce Generation: Unique, random Initialization Vector (IV) for the encryption.
-			nonce := make([]byte, gcm.NonceSize())                     // Creates a buffer for the Initialization Vector (Nonce)
-			if _, err := io.ReadFull(rand.Reader, nonce); err != nil { // Fills the nonce buffer with random data.
-				return fmt.Errorf("failed to generate nonce for %s: %v", path, err)
+			if !sealHideNames && !sealDryRun { // Random tokens never collide; dry runs have no password to check with.
+				if err := aegis.CheckSealTarget(path, out, existingKeys); err != nil {
+					errorf("%s Cannot seal %s: %v. Skipping.\n", markFail, path, err)
+					failures.add(path, "%v", err)
+					return nil
+				}
 			}
+			sealedFrom[out] = path
+			resumed := sealResume && sealedByEarlierRun(path, out, existingKeys) // Only the bookkeeping below is redone for it.
 
-			// --- FILENAME LOGIC: Embed Extension ---
-			// Embed the original file extension (e.g., .txt) into the encrypted data.
-			originalExt := []byte(filepath.Ext(path))                 // Extracts the original extension (e.g., .txt).
-			plaintextWithExt := append(originalExt, 0x00)             // Null terminator separates extension
-			plaintextWithExt = append(plaintextWithExt, plaintext...) // Appends the actual file content to be encrypted.
+			linkNote := "" // Followed links also show their target in messages.
+			if linkTarget != "" {
+				linkNote = fmt.Sprintf(" (symlink to '%s')", linkTarget)
+			}
 
-			// 5. Encryption: Seals the data using GCM (output includes ciphertext and authentication tag).
-			ciphertext := gcm.Seal(nil, nonce, plaintextWithExt, nil)
-			// Final file format: [Salt] + [Nonce] + [Ciphertext + Auth Tag]
-			final := append(salt, append(nonce, ciphertext...)...)
+			if sealDryRun { // Reports the planned action and stops before any crypto or file I/O.
+				fmt.Printf("   Would seal '%s'%s -> '%s'\n", path, linkNote, out)
+				filesSealed++
+				bytesSealed += info.Size()
+				return nil
+			}
 
-			// Construct the clean output filename (remove original extension, add .aegis)
-			baseName := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)) // Removes old extension from filename.
-			dirPath := filepath.Dir(path)                                           // Gets the directory part of the path.
-			out := filepath.Join(dirPath, baseName+".aegis")                        // Joins path with the new masked filename.
-			// Write output and clean up original file.
-			if err := os.WriteFile(out, final, 0600); err != nil { // Writes the final encrypted data to the new file.
-				return fmt.Errorf("failed to write sealed file %s: %v", out, err)
+			if sealOutput != "" { // Creates intermediate directories in the output tree.
+				if err := os.MkdirAll(dirPath, 0700); err != nil {
+					return fmt.Errorf("failed to create output directory %s: %v", dirPath, err)
+				}
 			}
 
-			if err := os.Remove(path); err != nil { // Deletes the original plaintext file.
-				fmt.Printf("Warning: Failed to remove original file %s: %v\n", path, err) // Warns if deletion fails.
+			// Crypto Setup: reuse the session key, or derive a unique one per file with --per-file-salt.
+			key := sessionKey
+			if sealPerFileSalt && !resumed {
+				key, err = crypto.NewKeyfileKey(password, keyfile, sealKDF)
+				if err != nil {
+					return fmt.Errorf("%s: %v", path, err) // Returns error for fatal crypto failure.
+				}
 			}
 
-			filesSealed++                                                   // Increments success counter.
-			fmt.Printf("✅ Sealed '%s' -> '%s'\n", path, filepath.Base(out)) //Prints success message.
-			return nil                                                      // Returns nil to continue the filepath.Walk traversal.
+			var entry manifestEntry // Hashed before sealing, so the manifest describes exactly what unseal should restore.
+			if sealWriteManifest {
+				if entry, err = hashFile(path); err != nil {
+					errorf("%s Failed to hash %s for the manifest: %v. Skipping.\n", markFail, path, err)
+					failures.add(path, "could not hash for the manifest: %v", err)
+					return nil
+				}
+				entry.ModTime = info.ModTime().UTC()
+			}
+
+			// Encryption: Streams the file through GCM chunk by chunk straight into the output file.
+			if resumed {
+				// Already written; the manifests, checksums and removal of the original still follow.
+			} else if err := aegis.SealWithKey(path, out, key, sealCompress); err != nil { // The original is left untouched on failure.
+				errorf("%s Failed to seal %s: %v. Skipping.\n", markFail, path, err)
+				failures.add(path, "%v", err)
+				return nil
+			}
+
+			if checksums != nil { // Hashes what was written, so later tampering with the sealed file shows.
+				if err := recordChecksum(checksums, sealedRoot, out); err != nil {
+					warnf("Warning: Could not record %s in %s: %v\n", out, checksumManifestFile, err)
+				}
+			}
+
+			if sealWriteManifest {
+				manifestFiles[filepath.ToSlash(rel)] = entry
+				if linkTarget != "" { // Unseal restores a regular file; the manifest remembers it was a link.
+					manifestLinks[filepath.ToSlash(rel)] = linkTarget
+				}
+			}
+
+			if sealHideNames { // Remembers the original name for this directory's manifest.
+				if hiddenNames[dirPath] == nil {
+					hiddenNames[dirPath] = make(nameManifest)
+				}
+				hiddenNames[dirPath][filepath.Base(out)] = filepath.Base(path)
+			}
+
+			if !retainOriginals && sealShred { // Overwritten first, so the plaintext is not simply left in free blocks.
+				if err := shredFile(path, sealShredPasses); err != nil { // A followed link is removed, never its target.
+					warnf("Warning: Failed to shred original file %s: %v\n", path, err)
+				}
+			} else if !retainOriginals { // Originals are only deleted when neither --keep nor --output was requested.
+				if err := os.Remove(path); err != nil { // Deletes the original plaintext file (for a followed link, the link itself).
+					warnf("Warning: Failed to remove original file %s: %v\n", path, err) // Warns if deletion fails.
+				}
+			}
+
+			if resumed {
+				verbosef("   Skipping (sealed by an earlier run): %s\n", path)
+				filesResumed++
+				return nil
+			}
+			filesSealed++ // Increments success counter.
+			bytesSealed += info.Size()
+			display := filepath.Base(out) // In-place sealing only changes the file name; output mode shows the full target.
+			if sealOutput != "" {
+				display = out
+			}
+			if !quiet { // --quiet leaves only errors and the summary.
+				printColorf(colorGreen, "%s Sealed '%s'%s -> '%s'\n", markOK, path, linkNote, display) //Prints success message.
+			}
+			return nil // Returns nil to continue the filepath.Walk traversal.
 		})
+		walkErr := filepath.Walk(dir, walkFn)
+		bar.clear()
+		interrupted := errors.Is(walkErr, context.Canceled) // The manifests below still cover what was sealed.
+		if interrupted {
+			walkErr = nil
+		}
 		// Check for fatal errors from filepath.Walk
 		if walkErr != nil { // Checks if the CRITICAL FIX triggered (i.e., a fatal error occurred).
 			// This block catches the fatal error returned from filepath.Walk (e.g., non-existent directory)
-			fmt.Printf("\n\n🔥 Fatal Error during sealing: %v\n", walkErr)
-			os.Exit(1) // Exits the program with a non-zero status code (failure).
+			errorf("\n\n%s Fatal Error during sealing: %v\n", markFatal, walkErr)
+			os.Exit(exitFatal) // Exits the program with a non-zero status code (failure).
+
+		}
+
+		// Name manifests: one encrypted manifest per directory that received hidden names.
+		var written []string // Manifests rewritten by this run, listed in the checksum manifest below.
+		for outDir, names := range hiddenNames {
+			if err := saveNameManifest(outDir, names, password, keyfile, sessionKey); err != nil {
+				errorf("\n\n%s Fatal Error writing name manifest in %s: %v\n", markFatal, outDir, err)
+				os.Exit(exitFatal) // Without the manifest the original names cannot be restored.
+			}
+			written = append(written, filepath.Join(outDir, namesManifestFile))
+		}
 
+		// Seal manifest: written at the root of the sealed tree and merged with one from an earlier run.
+		if !sealDryRun && (sealWriteManifest && len(manifestFiles) > 0 || len(manifestDirs) > 0) {
+			if err := saveSealManifest(sealedRoot, &sealManifest{Files: manifestFiles, Links: manifestLinks, Dirs: manifestDirs}, password, keyfile, sessionKey); err != nil {
+				errorf("\n\n%s Fatal Error writing %s: %v\n", markFatal, sealManifestFile, err)
+				os.Exit(exitFatal)
+			}
+			written = append(written, filepath.Join(sealedRoot, sealManifestFile))
 		}
 
-		// Final summary output
-		fmt.Printf("\n✨ Sealing complete for directory '%s'.\n", dir)
-		fmt.Printf("   Successfully sealed %d files.\n", filesSealed)
-		if filesSkipped > 0 { // Prints skipped items only if necessary.
-			fmt.Printf("   Skipped %d items (already sealed, symlinks, or excluded).\n", filesSkipped)
+		// Checksum manifest: signed last, so it also covers the manifests written above.
+		if checksums != nil {
+			for _, path := range written {
+				if err := recordChecksum(checksums, sealedRoot, path); err != nil {
+					warnf("Warning: Could not record %s in %s: %v\n", path, checksumManifestFile, err)
+				}
+			}
+			if err := saveChecksumManifest(sealedRoot, checksums, password, sealKDF); err != nil {
+				errorf("\n\n%s Fatal Error writing %s: %v\n", markFatal, checksumManifestFile, err)
+				os.Exit(exitFatal)
+			}
+		}
+
+		if sealJSON { // Replaces both the dry-run and the final summary below; the exit code is the same.
+			printJSONSummary(jsonOut, sealSummary{Sealed: filesSealed, runSummary: runSummary{
+				Skipped: int64(filesSkipped), Failed: failures.count(), Bytes: bytesSealed, Duration: time.Since(started).Seconds(),
+				Directory: dir, DryRun: sealDryRun, Interrupted: interrupted, Failures: failures.sorted(),
+			}, Resumed: filesResumed})
+		} else if sealDryRun { // Dry-run summary: nothing was written or deleted.
+			if interrupted {
+				fmt.Printf("\n%s Dry run interrupted for directory '%s'.\n", markStop, dir)
+			} else {
+				fmt.Printf("\n%s Dry run complete for directory '%s'.\n", markDryRun, dir)
+			}
+			fmt.Printf("   Would seal %d files (%s), would skip %d.\n", filesSealed, formatSize(bytesSealed), filesSkipped)
+		} else { // Final summary output
+			if interrupted {
+				fmt.Printf("\n%s Sealing interrupted in directory '%s'; the remaining files were left as they are.\n", markStop, dir)
+			} else {
+				fmt.Printf("\n%s Sealing complete for directory '%s'.\n", markDone, dir)
+			}
+			if retainOriginals { // Makes it explicit that nothing was deleted.
+				fmt.Printf("   Sealed %d files (%s) in %s (originals retained).\n", filesSealed, formatSize(bytesSealed), formatElapsed(time.Since(started)))
+			} else {
+				fmt.Printf("   Successfully sealed %d files (%s) in %s.\n", filesSealed, formatSize(bytesSealed), formatElapsed(time.Since(started)))
+			}
+			if filesSkipped > 0 { // Prints skipped items only if necessary.
+				fmt.Printf("   Skipped %d items (already sealed, symlinks, hidden, or excluded by pattern, extension or size).\n", filesSkipped)
+			}
+			if sealResume {
+				fmt.Printf("   Resumed: skipped %d already-sealed files.\n", filesResumed)
+			}
+			if failures.count() > 0 { // Scripts can tell a partial failure from a fatal one by the exit code.
+				printColorf(colorRed, "   Failed to seal %d files.\n", failures.count())
+				failures.print() // Lists them again, since the inline errors have long scrolled away on a big run.
+			}
+		}
+		switch {
+		case interrupted:
+			os.Exit(exitInterrupted)
+		case sealDryRun: // Nothing was attempted, so a dry run exits 0 even with failures listed.
+		case failures.count() > 0:
+			os.Exit(exitPartial)
 		}
 	},
 }
 
+// checkSealKDF validates the --scrypt-* flags and, unless --allow-weak-kdf is set, rejects a
+// cost below crypto.MinKDFParams, at which an attacker with a sealed file can try passwords cheaply.
+func checkSealKDF() error {
+	if err := sealKDF.Validate(); err != nil {
+		return err
+	}
+	if err := sealKDF.CheckStrength(); err != nil && !sealAllowWeakKDF {
+		return fmt.Errorf("%v; a key this cheap to derive makes guessing the password offline fast. Raise --scrypt-n/--scrypt-r, or pass --allow-weak-kdf to seal anyway (e.g. for tests)", err)
+	}
+	return nil
+}
+
+// sealedByEarlierRun reports whether out already holds a current seal of path: it opens with
+// keys, and records path's name, extension and modification time. A file edited since then does
+// not match and is sealed again.
+func sealedByEarlierRun(path, out string, keys *crypto.KeyCache) bool {
+	info, err := os.Stat(path) // The target's time for a followed link, as sealing records.
+	if err != nil {
+		return false
+	}
+	f, err := os.Open(out)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	payload, err := crypto.Open(f, filepath.Base(out), keys) // Decrypts only the header and metadata.
+	if err != nil {
+		return false
+	}
+	_, ext := aegis.SplitExt(filepath.Base(path))
+	return payload.HasExt && payload.Ext == ext && payload.Name == filepath.Base(path) && payload.ModTime.Equal(info.ModTime())
+}
+
+// saveNameManifest merges names into the directory's existing manifest (from an earlier run)
+// and writes it back encrypted. key may be nil in --per-file-salt mode, in which case a
+// dedicated key is derived for the manifest (with the --keyfile content, if any).
+func saveNameManifest(dir string, names nameManifest, password string, keyfile []byte, key *crypto.Key) error {
+	existing, err := readNameManifest(dir, crypto.NewKeyfileKeyCache(password, keyfile))
+	if err != nil {
+		return fmt.Errorf("could not read existing manifest: %v", err)
+	}
+	for token, name := range existing {
+		if _, ok := names[token]; !ok {
+			names[token] = name
+		}
+	}
+	if key == nil {
+		if key, err = crypto.NewKeyfileKey(password, keyfile, sealKDF); err != nil {
+			return err
+		}
+	}
+	return writeNameManifest(dir, names, key)
+}
+
 func init() {
+	sealCmd.Flags().StringVar(&sealPasswordFile, "password-file", "", "Read the password from the first line of this file")
+	sealCmd.Flags().BoolVar(&sealPasswordStdin, "password-stdin", false, "Read the password from the first line of stdin, e.g. echo \"$PASS\" | aegis seal --password-stdin ./dir")
+	sealCmd.Flags().IntVar(&sealPromptRetries, "prompt-retries", 2, "Times both passwords may be re-entered at the prompt when the confirmation does not match (0 disables)")
+	sealCmd.Flags().StringVar(&sealKeyfile, "keyfile", "", "Also derive the key from this file's content, so unsealing needs both the password and the file")
+	sealCmd.Flags().BoolVar(&sealDryRun, "dry-run", false, "Show which files would be sealed or skipped without changing anything")
+	sealCmd.Flags().IntVar(&sealKDF.N, "scrypt-n", crypto.DefaultKDFParams.N, "scrypt CPU/memory cost parameter N (power of two)")
+	sealCmd.Flags().IntVar(&sealKDF.R, "scrypt-r", crypto.DefaultKDFParams.R, "scrypt block size parameter r")
+	sealCmd.Flags().IntVar(&sealKDF.P, "scrypt-p", crypto.DefaultKDFParams.P, "scrypt parallelization parameter p")
+	sealCmd.Flags().BoolVar(&sealAllowWeakKDF, "allow-weak-kdf", false, "Seal with scrypt parameters below the minimum (N=16384 at r=8), e.g. to keep tests fast")
+	sealCmd.Flags().BoolVar(&sealPerFileSalt, "per-file-salt", false, "Derive a separate key for every file (slower; one scrypt run per file)")
+	sealCmd.Flags().StringVarP(&sealOutput, "output", "o", "", "Write sealed files under this directory, mirroring the source tree, and keep the originals")
+	sealCmd.Flags().BoolVar(&sealHideNames, "hide-names", false, "Replace sealed file names with random tokens; original names are kept in an encrypted per-directory manifest")
+	sealCmd.Flags().BoolVar(&sealCompress, "compress", false, "Compress file content before encryption (already-compressed formats are stored as is)")
+	sealCmd.Flags().StringArrayVar(&sealExcludes, "exclude", nil, "Skip files and directories matching this glob (repeatable; a trailing / matches directories only)")
+	sealCmd.Flags().StringArrayVar(&sealExcludeExts, "exclude-ext", nil, "Skip files with this extension, e.g. md or .md (repeatable, case-insensitive)")
+	sealCmd.Flags().StringArrayVar(&sealOnlyExts, "only-ext", nil, "Seal only files with this extension (repeatable, case-insensitive); others are skipped")
+	sealCmd.Flags().BoolVar(&sealNoDefaultExcludes, "no-default-excludes", false, "Do not skip the built-in directories (.git, vendor, node_modules, target)")
+	sealCmd.Flags().BoolVarP(&sealYes, "yes", "y", false, "Do not ask for confirmation before sealing and deleting the originals")
+	sealCmd.Flags().BoolVar(&sealForce, "force", false, "Seal git repositories found in the directory instead of refusing to")
+	sealCmd.Flags().StringVar(&sealMaxFileSize, "max-file-size", "", "Skip files larger than this size (e.g. 100MB)")
+	sealCmd.Flags().StringVar(&sealMinFileSize, "min-file-size", "", "Skip files smaller than this size (e.g. 1KB)")
+	sealCmd.Flags().BoolVar(&sealIncludeEmptyDirs, "include-empty-dirs", false, "Record the directory structure in "+sealManifestFile+" so unseal recreates empty directories")
+	sealCmd.Flags().BoolVar(&sealRecursive, "recursive", true, "Seal files in subdirectories too; --recursive=false seals only the files directly in the directory")
+	sealCmd.Flags().IntVar(&sealMaxDepth, "max-depth", 0, "Seal files at most this many levels below the directory (1 is the directory's own files); 0 means no limit")
+	sealCmd.Flags().BoolVar(&sealFollowSymlinks, "follow-symlinks", false, "Seal the files symlinks point to (and walk linked directories) instead of skipping symlinks")
+	sealCmd.Flags().BoolVar(&sealSkipHidden, "skip-hidden", false, "Skip files and directories whose name starts with '.' (credential files such as .env are still sealed)")
+	sealCmd.Flags().BoolVar(&sealWriteManifest, "manifest", false, "Record every sealed file's path, size and SHA-256 in an encrypted "+sealManifestFile+" that unseal checks")
+	sealCmd.Flags().BoolVar(&sealChecksumManifest, "checksum-manifest", false, "Record the SHA-256 of every sealed file in "+checksumManifestFile+", signed with a key derived from the password, for verify --manifest")
+	sealCmd.Flags().StringVar(&sealStdioName, "name", defaultStdioName, "With '-': original file name of the input; its extension is restored by unseal and the output must be saved as <name without extension>.aegis")
+	sealCmd.Flags().BoolVar(&sealResume, "resume", false, "Continue an interrupted run: skip files an earlier run already sealed and that are unchanged since (for --keep and --output)")
+	sealCmd.Flags().BoolVar(&sealJSON, "json", false, "Print the final summary as a JSON object on stdout (sealed, skipped, failed, bytes, duration, failures); other output goes to stderr")
+	sealCmd.Flags().BoolVarP(&sealKeep, "keep", "k", false, "Keep the original files instead of deleting them after sealing")
+	sealCmd.Flags().BoolVar(&sealShred, "shred", false, "Overwrite each original with random data before deleting it (best effort; see the README for limits)")
+	sealCmd.Flags().IntVar(&sealShredPasses, "shred-passes", 1, "Number of random overwrites --shred makes of each original")
 	RootCmd.AddCommand(sealCmd)
 }
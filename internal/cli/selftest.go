@@ -0,0 +1,186 @@
+package cli
+
+import (
+	"aegis/internal/crypto"
+	"aegis/pkg/aegis"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// selftestPassword is the fixed password the self-test seals with. Nothing it seals outlives the run.
+const selftestPassword = "aegis-selftest"
+
+// selftestCheck is one step of the self-test; run returns nil when the step passes.
+type selftestCheck struct {
+	name string
+	run  func() error
+}
+
+var selftestCmd = &cobra.Command{
+	Use:   "selftest",
+	Short: "Check that sealing and unsealing work on this machine",
+	Long: `Seal a generated file in a temporary directory with a fixed test password, unseal it again and
+compare the content, extension, permissions and modification time with the original. Wrong passwords,
+tampered data and renamed files must be rejected. Nothing outside the temporary directory is touched,
+and it is removed afterwards. Run it after an upgrade or on a new platform before trusting aegis with real data.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		tmp, err := os.MkdirTemp("", "aegis-selftest-")
+		if err != nil {
+			errorf("Error: %v\n", err)
+			os.Exit(exitFatal)
+		}
+
+		fmt.Printf("%s Running self-test in '%s'...\n", markTest, tmp)
+		failed, total := runSelftest(tmp)
+		if err := os.RemoveAll(tmp); err != nil {
+			warnf("Warning: Failed to remove %s: %v\n", tmp, err)
+		}
+
+		if failed > 0 {
+			fmt.Printf("\n%s Self-test FAILED: %d of %d checks failed. Do not trust this build with real data.\n", markError, failed, total)
+			os.Exit(exitFatal)
+		}
+		fmt.Printf("\n%s Self-test passed (%d checks).\n", markDone, total)
+	},
+}
+
+// runSelftest runs every check against files in dir and returns how many failed out of how many.
+// The sealing and unsealing go through the same functions as the seal and unseal commands.
+func runSelftest(dir string) (failed, total int) {
+	original := filepath.Join(dir, "sample.txt")
+	var content bytes.Buffer // Text, so it compresses, and longer than one chunk.
+	for i := 0; content.Len() < 200*1024; i++ {
+		fmt.Fprintf(&content, "line %d: the quick brown fox jumps over the lazy dog\n", i)
+	}
+	modTime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	const mode os.FileMode = 0640
+
+	sealed := filepath.Join(dir, "sample.aegis")
+	keys := crypto.NewKeyCache(selftestPassword)
+	var key *crypto.Key
+
+	// open decrypts the sealed file at path, reading it to the end so every chunk is authenticated.
+	open := func(path string, keys *crypto.KeyCache) (*crypto.Payload, []byte, error) {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		defer f.Close()
+		payload, err := crypto.Open(f, filepath.Base(path), keys)
+		if err != nil {
+			return nil, nil, err
+		}
+		data, err := io.ReadAll(payload.Content)
+		return payload, data, err
+	}
+	// roundTrip seals the original (optionally compressed) and checks what unsealing it restores.
+	roundTrip := func(compress bool) error {
+		if err := aegis.SealWithKey(original, sealed, key, compress); err != nil {
+			return fmt.Errorf("seal: %v", err)
+		}
+		payload, data, err := open(sealed, keys)
+		if err != nil {
+			return fmt.Errorf("unseal: %v", err)
+		}
+		restored := filepath.Join(dir, "restored"+payload.Ext)
+		if err := aegis.WritePlaintext(restored, bytes.NewReader(data)); err != nil {
+			return fmt.Errorf("write: %v", err)
+		}
+		switch written, err := os.ReadFile(restored); {
+		case err != nil:
+			return err
+		case !bytes.Equal(written, content.Bytes()):
+			return errors.New("restored content differs from the original")
+		case !payload.HasExt || payload.Ext != ".txt":
+			return fmt.Errorf("restored extension is %q, want .txt", payload.Ext)
+		case payload.Name != "sample.txt":
+			return fmt.Errorf("restored name is %q, want sample.txt", payload.Name)
+		case payload.Mode != mode:
+			return fmt.Errorf("restored permissions are %v, want %v", payload.Mode, mode)
+		case !payload.ModTime.Equal(modTime):
+			return fmt.Errorf("restored modification time is %v, want %v", payload.ModTime, modTime)
+		case payload.Compressed != compress:
+			return fmt.Errorf("compressed flag is %v, want %v", payload.Compressed, compress)
+		}
+		return os.Remove(restored)
+	}
+	// rejects reports an error unless opening the sealed file at path fails authentication with
+	// want, telling a wrong password from a damaged file.
+	rejects := func(path string, keys *crypto.KeyCache, want error) error {
+		_, _, err := open(path, keys)
+		if errors.Is(err, want) {
+			return nil
+		}
+		if err == nil {
+			return errors.New("was accepted")
+		}
+		if errors.Is(err, crypto.ErrDecryptFailed) {
+			return fmt.Errorf("failed authentication with the wrong reason: %v", err)
+		}
+		return fmt.Errorf("failed without an authentication error: %v", err)
+	}
+
+	checks := []selftestCheck{
+		{"write a sample file", func() error {
+			if err := os.WriteFile(original, content.Bytes(), mode); err != nil {
+				return err
+			}
+			if err := os.Chmod(original, mode); err != nil { // Not reduced by the umask.
+				return err
+			}
+			return os.Chtimes(original, modTime, modTime)
+		}},
+		{"derive a key", func() (err error) {
+			key, err = crypto.NewKey(selftestPassword, crypto.DefaultKDFParams)
+			return err
+		}},
+		{"seal and unseal (multi-chunk)", func() error { return roundTrip(false) }},
+		{"seal and unseal (compressed)", func() error { return roundTrip(true) }},
+		{"reject a wrong password", func() error { return rejects(sealed, crypto.NewKeyCache("wrong-password"), crypto.ErrWrongPassword) }},
+		{"reject tampered data", func() error {
+			data, err := os.ReadFile(sealed)
+			if err != nil {
+				return err
+			}
+			data[len(data)/2] ^= 0x01 // Inside the encrypted content, past the header.
+			tampered := filepath.Join(dir, "tampered.aegis")
+			if err := os.WriteFile(tampered, data, 0600); err != nil {
+				return err
+			}
+			return rejects(tampered, keys, crypto.ErrCorrupted)
+		}},
+		{"reject a renamed file", func() error {
+			renamed := filepath.Join(dir, "other.aegis")
+			if err := os.Rename(sealed, renamed); err != nil {
+				return err
+			}
+			return rejects(renamed, keys, crypto.ErrCorrupted)
+		}},
+	}
+
+	for _, c := range checks {
+		total++
+		if err := c.run(); err != nil {
+			fmt.Printf("   %s %s: %v\n", markFail, c.name, err)
+			failed++
+			if total <= 2 { // Nothing else can run without the sample file and key.
+				return failed, len(checks)
+			}
+			continue
+		}
+		fmt.Printf("   %s %s\n", markOK, c.name)
+	}
+	return failed, total
+}
+
+func init() {
+	RootCmd.AddCommand(selftestCmd)
+}
@@ -0,0 +1,47 @@
+package cli
+
+import (
+	"crypto/rand"
+	"fmt"
+	"io"
+	"os"
+)
+
+// shredFile overwrites the regular file at path with random data, passes times, flushing each
+// pass to disk, and then removes it. This is best effort only: copy-on-write and journaling
+// filesystems, SSD wear levelling and snapshots can keep the old blocks wherever the overwrite
+// lands. Anything other than a regular file, such as a followed symlink whose target must be
+// left alone, is removed without being overwritten.
+func shredFile(path string, passes int) error {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return err
+	}
+	if info.Mode().IsRegular() {
+		if err := overwriteFile(path, info.Size(), passes); err != nil {
+			return fmt.Errorf("could not overwrite before removing: %v", err)
+		}
+	}
+	return os.Remove(path)
+}
+
+// overwriteFile writes size random bytes over the start of the file at path, passes times.
+func overwriteFile(path string, size int64, passes int) error {
+	f, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	for i := 0; i < passes; i++ {
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		if _, err := io.CopyN(f, rand.Reader, size); err != nil {
+			return err
+		}
+		if err := f.Sync(); err != nil { // Each pass must reach the disk, or the cache may merge them.
+			return err
+		}
+	}
+	return f.Close()
+}
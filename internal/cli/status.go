@@ -0,0 +1,146 @@
+package cli
+
+import (
+	"aegis/internal/atomicfile"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// statusExcludes holds the repeatable --exclude glob patterns, applied in addition to the defaults.
+var statusExcludes []string
+
+// statusNoDefaultExcludes, when set via --no-default-excludes, disables the built-in exclude list.
+var statusNoDefaultExcludes bool
+
+// statusCountOnly, when set via --count-only, prints only the totals, without the file listings.
+var statusCountOnly bool
+
+var statusCmd = &cobra.Command{
+	Use:   "status [directory]",
+	Short: "Show which files are sealed and which are plaintext",
+	Long:  `List the sealed (.aegis) and plaintext files in a directory with their counts and total sizes. No password is needed; directories excluded from sealing are skipped the same way seal skips them.`,
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		dir := args[0]
+
+		var excludeList []string
+		if !statusNoDefaultExcludes {
+			excludeList = append(excludeList, defaultExcludes...)
+		}
+		excludeList = append(excludeList, statusExcludes...)
+		excludes, err := newExcludeMatcher(excludeList)
+		if err != nil {
+			errorf("Error: %v\n", err)
+			os.Exit(exitFatal)
+		}
+		if _, err := excludes.loadIgnoreFile(dir); err != nil {
+			errorf("Error: %v\n", err)
+			os.Exit(exitFatal)
+		}
+
+		var sealed, plain []string        // Relative paths in walk order.
+		var sealedBytes, plainBytes int64 // Total on-disk sizes.
+		sizes := make(map[string]int64)
+		var skipped int // Symlinks and excluded files.
+		walkErr := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			rel, _ := filepath.Rel(dir, path)
+			excluded := path != dir && excludes.match(rel, info.IsDir())
+			if info.IsDir() {
+				if excluded {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			// aegis bookkeeping files are neither sealed content nor plaintext to protect.
+			if info.Name() == namesManifestFile || rel == sealManifestFile || rel == checksumManifestFile || rel == ignoreFileName || atomicfile.IsTemp(info.Name()) {
+				return nil
+			}
+			if excluded || info.Mode()&os.ModeSymlink != 0 {
+				skipped++
+				return nil
+			}
+
+			sizes[rel] = info.Size()
+			if strings.HasSuffix(path, ".aegis") {
+				sealed = append(sealed, rel)
+				sealedBytes += info.Size()
+			} else {
+				plain = append(plain, rel)
+				plainBytes += info.Size()
+			}
+			return nil
+		})
+		if walkErr != nil {
+			errorf("\n\n%s Fatal Error during status scan: %v\n", markFatal, walkErr)
+			os.Exit(exitFatal)
+		}
+
+		fmt.Printf("%s Status of directory '%s'\n", markStats, dir)
+		if statusCountOnly { // Totals only, one per line, for scripts and quick checks.
+			fmt.Printf("%s Sealed: %d files, %d bytes\n", markSeal, len(sealed), sealedBytes)
+			fmt.Printf("%s Plaintext: %d files, %d bytes\n", markFile, len(plain), plainBytes)
+			fmt.Printf("%s Total: %d files, %d bytes\n", markList, len(sealed)+len(plain), sealedBytes+plainBytes)
+		} else {
+			fmt.Printf("\n%s Sealed: %d files, %s\n", markSeal, len(sealed), formatSize(sealedBytes))
+			for _, rel := range sealed {
+				fmt.Printf("   %s (%s)\n", rel, formatSize(sizes[rel]))
+			}
+			fmt.Printf("\n%s Plaintext: %d files, %s\n", markFile, len(plain), formatSize(plainBytes))
+			for _, rel := range plain {
+				fmt.Printf("   %s (%s)\n", rel, formatSize(sizes[rel]))
+			}
+			fmt.Println()
+		}
+
+		switch {
+		case len(sealed) == 0 && len(plain) == 0:
+			fmt.Printf("%s No files found.\n", markDone)
+		case len(plain) == 0:
+			fmt.Printf("%s Fully sealed.\n", markDone)
+		case len(sealed) == 0:
+			fmt.Printf("%s Not sealed.\n", markDone)
+		default:
+			fmt.Printf("%s Partially sealed: %d of %d files.\n", markDone, len(sealed), len(sealed)+len(plain))
+		}
+		if skipped > 0 {
+			fmt.Printf("   Skipped %d items (symlinks or excluded).\n", skipped)
+		}
+	},
+}
+
+// formatSize renders a byte count with a binary unit, e.g. "1.5 MB".
+func formatSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// formatElapsed renders a duration for a summary line: 18.4s, or 350ms below a second.
+func formatElapsed(d time.Duration) string {
+	if d < time.Second {
+		return d.Round(time.Millisecond).String()
+	}
+	return d.Round(100 * time.Millisecond).String()
+}
+
+func init() {
+	statusCmd.Flags().StringArrayVar(&statusExcludes, "exclude", nil, "Skip files and directories matching this glob (repeatable; a trailing / matches directories only)")
+	statusCmd.Flags().BoolVar(&statusCountOnly, "count-only", false, "Print only the sealed, plaintext and total counts and sizes, not every file")
+	statusCmd.Flags().BoolVar(&statusNoDefaultExcludes, "no-default-excludes", false, "Do not skip the built-in directories (.git, vendor, node_modules, target)")
+	RootCmd.AddCommand(statusCmd)
+}
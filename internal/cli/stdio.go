@@ -0,0 +1,132 @@
+package cli
+
+import (
+	"aegis/internal/crypto"
+	"aegis/pkg/aegis"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// stdioArg is the argument that makes seal and unseal stream between stdin and stdout.
+const stdioArg = "-"
+
+// defaultStdioName is the file name assumed for streamed data when --name is not given.
+const defaultStdioName = "stdin"
+
+// sealStdioName holds seal --name: the original file name of plaintext read from stdin.
+var sealStdioName string
+
+// unsealStdioName holds unseal --name: the sealed file name the data on stdin is bound to.
+var unsealStdioName string
+
+// checkStdioFlags rejects flags that only make sense for a directory walk, and a password that
+// would have to be typed on stdin, which carries the data when streaming.
+func checkStdioFlags(cmd *cobra.Command, passwordFile string, walkFlags ...string) error {
+	if cmd.Flags().Changed("password-stdin") {
+		return fmt.Errorf("stdin carries the data; --password-stdin cannot be used with '%s'", stdioArg)
+	}
+	for _, name := range walkFlags {
+		if cmd.Flags().Changed(name) {
+			return fmt.Errorf("--%s cannot be used with '%s'", name, stdioArg)
+		}
+	}
+	if passwordPrompted(passwordFile, passwordEnvVar) {
+		return fmt.Errorf("stdin carries the data; supply the password with --password-file or %s", passwordEnvVar)
+	}
+	return nil
+}
+
+// sealStdio reads plaintext from stdin and writes it sealed to stdout. The header records the
+// total length, so the input is read into memory first. The output is bound to the sealed form
+// of --name (stdin.aegis by default), the name it must be saved under to unseal in a directory.
+func sealStdio(cmd *cobra.Command) {
+	if err := checkStdioFlags(cmd, sealPasswordFile, "output", "dry-run", "keep", "yes", "force", "hide-names", "manifest", "checksum-manifest", "include-empty-dirs",
+		"exclude", "exclude-ext", "only-ext", "max-file-size", "min-file-size", "no-default-excludes", "skip-hidden", "follow-symlinks", "json", "resume", "shred", "shred-passes", "max-depth", "prompt-retries"); err != nil {
+		errorf("Error: %v\n", err)
+		os.Exit(exitFatal)
+	}
+	if err := checkSealKDF(); err != nil {
+		errorf("Error: %v\n", err)
+		os.Exit(exitFatal)
+	}
+	password, err := readPassword(sealPasswordFile, false)
+	if err != nil {
+		errorf("Error reading password: %v\n", err)
+		os.Exit(exitFatal)
+	}
+	name := filepath.Base(sealStdioName)
+	stem, ext := aegis.SplitExt(name)
+	sealedName := stem + ".aegis"
+
+	data, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		errorf("Error reading stdin: %v\n", err)
+		os.Exit(exitFatal)
+	}
+	keyfile, err := readKeyfile(sealKeyfile)
+	if err != nil {
+		errorf("Error: %v\n", err)
+		os.Exit(exitFatal)
+	}
+	key, err := crypto.NewKeyfileKey(password, keyfile, sealKDF)
+	if err != nil {
+		errorf("Error: %v\n", err)
+		os.Exit(exitFatal)
+	}
+
+	meta := crypto.Metadata{Mode: 0600, ModTime: time.Now(), Ext: ext, Name: name}
+	if err := aegis.Seal(os.Stdout, sealedName, meta, bytes.NewReader(data), int64(len(data)), sealCompress, key); err != nil {
+		errorf("%s Failed to seal stdin: %v\n", markFail, err)
+		os.Exit(exitFatal)
+	}
+	debugf("sealed %d bytes from stdin, bound to the name %s\n", len(data), sealedName)
+}
+
+// unsealStdio reads a sealed file from stdin and writes its plaintext to stdout as each chunk is
+// authenticated. If a later chunk fails, the output is incomplete and the exit code is 2, so
+// pipelines must check it before using the output.
+func unsealStdio(cmd *cobra.Command) {
+	if err := checkStdioFlags(cmd, unsealPasswordFile, "output", "dry-run", "remove-sealed", "force", "jobs", "match", "follow-symlinks", "json", "max-depth"); err != nil {
+		errorf("Error: %v\n", err)
+		os.Exit(exitFatal)
+	}
+	password, err := readPassword(unsealPasswordFile, false)
+	if err != nil {
+		errorf("Error reading password: %v\n", err)
+		os.Exit(exitFatal)
+	}
+	keyfile, err := readKeyfile(unsealKeyfile)
+	if err != nil {
+		errorf("Error: %v\n", err)
+		os.Exit(exitFatal)
+	}
+	name := filepath.Base(unsealStdioName)
+
+	payload, err := crypto.Open(os.Stdin, name, crypto.NewKeyfileKeyCache(password, keyfile))
+	if err == nil {
+		debugf("stdin: format v%d, name %q, extension %q, compressed=%v\n", payload.Version, payload.Name, payload.Ext, payload.Compressed)
+		_, err = io.Copy(os.Stdout, payload.Content)
+	}
+	switch {
+	case errors.Is(err, crypto.ErrKeyfileRequired):
+		errorf("%s stdin was sealed with a key file; supply it with --keyfile.\n", markError)
+		os.Exit(exitFatal)
+	case errors.Is(err, crypto.ErrDecryptFailed):
+		reason := decryptFailure(err, keyfile)
+		if !errors.Is(err, crypto.ErrWrongPassword) && !errors.Is(err, crypto.ErrCorrupted) { // Pre-v9 formats cannot tell which.
+			reason += fmt.Sprintf(" (stdin is checked as '%s'; see --name)", name)
+		}
+		errorf("%s Decryption FAILED for stdin: %s.\n", markError, reason)
+		os.Exit(exitPartial)
+	case err != nil:
+		errorf("%s Could not unseal stdin: %v\n", markFail, err)
+		os.Exit(exitPartial)
+	}
+}
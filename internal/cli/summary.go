@@ -0,0 +1,54 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+// runSummary is the aggregate result of a seal or unseal run that --json prints in place of
+// the human summary. sealSummary and unsealSummary embed it after the count of files the
+// command processed, so that count leads the object.
+type runSummary struct {
+	Skipped     int64         `json:"skipped"`
+	Failed      int           `json:"failed"`
+	Bytes       int64         `json:"bytes"`    // Plaintext bytes sealed or restored (or, in a dry run, that would be)
+	Duration    float64       `json:"duration"` // Seconds, not counting the password prompt
+	Directory   string        `json:"directory"`
+	DryRun      bool          `json:"dry_run"`
+	Interrupted bool          `json:"interrupted"`
+	Failures    []fileFailure `json:"failures"` // Sorted by path; an empty array rather than null
+}
+
+type sealSummary struct {
+	Sealed int `json:"sealed"`
+	runSummary
+	Resumed int `json:"resumed"` // Left as sealed by an earlier run (--resume)
+}
+
+type unsealSummary struct {
+	Unsealed int64 `json:"unsealed"`
+	runSummary
+	Existing         int64 `json:"existing"`                    // Left sealed because the output already exists
+	Unmatched        int64 `json:"unmatched"`                   // Left sealed by --match
+	ManifestProblems int   `json:"manifest_problems,omitempty"` // Listed files missing or different; exits 2 like failures
+}
+
+// jsonStdout sends everything a --json run prints for people (progress, per-file lines) to
+// stderr, so stdout carries the summary object alone, and returns the real stdout for it.
+func jsonStdout() io.Writer {
+	out := os.Stdout
+	os.Stdout = os.Stderr
+	return out
+}
+
+// printJSONSummary writes v to out as a single line of JSON.
+func printJSONSummary(out io.Writer, v any) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		errorf("Error: could not encode the summary: %v\n", err)
+		return
+	}
+	fmt.Fprintf(out, "%s\n", data)
+}
@@ -0,0 +1,174 @@
+package cli
+
+import (
+	"aegis/internal/crypto"
+	"aegis/pkg/aegis"
+	"archive/tar"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// unpackOutput holds --output: the directory the bundle is restored into.
+var unpackOutput string
+
+// unpackPasswordFile holds the --password-file path; when set it takes precedence over other password sources.
+var unpackPasswordFile string
+
+var unpackCmd = &cobra.Command{
+	Use:   "unpack <bundle.aegis> -o <directory>",
+	Short: "Restore a bundle created by pack",
+	Long: `Decrypt a bundle written by 'aegis pack' and extract its files, permissions and modification times
+under --output. Each file is written only once its content has been authenticated; existing files are never
+overwritten. Entries that would land outside the output directory are rejected.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		bundle := args[0]
+		if unpackOutput == "" {
+			errorf("Error: --output is required\n")
+			os.Exit(exitFatal)
+		}
+
+		f, err := os.Open(bundle)
+		if err != nil {
+			errorf("Error: %v\n", err)
+			os.Exit(exitFatal)
+		}
+		defer f.Close()
+
+		password, err := readPassword(unpackPasswordFile, false)
+		if err != nil {
+			errorf("Error reading password: %v\n", err)
+			os.Exit(exitFatal)
+		}
+
+		if !quiet {
+			fmt.Printf("%s Unpacking '%s' into '%s'...\n", markPack, bundle, unpackOutput)
+		}
+		payload, err := crypto.Open(f, filepath.Base(bundle), crypto.NewKeyCache(password))
+		switch {
+		case errors.Is(err, crypto.ErrDecryptFailed):
+			errorf("%s Could not open '%s': %s. Nothing was unpacked.\n", markError, bundle, decryptFailure(err, nil))
+			os.Exit(exitPartial)
+		case err != nil:
+			errorf("%s %s: %v\n", markFail, bundle, err)
+			os.Exit(exitFatal)
+		case !payload.HasExt || payload.Ext != bundleExt:
+			errorf("Error: '%s' is a sealed file, not a bundle; use 'aegis unseal' instead\n", bundle)
+			os.Exit(exitFatal)
+		}
+
+		if err := os.MkdirAll(unpackOutput, 0700); err != nil {
+			errorf("Error: %v\n", err)
+			os.Exit(exitFatal)
+		}
+
+		filesRestored, filesFailed, err := extractBundle(payload.Content, unpackOutput)
+		if err != nil {
+			errorf("%s Unpacking stopped: %v\n", markError, err)
+		}
+
+		fmt.Printf("\n%s Unpacking complete for '%s'.\n", markDone, bundle)
+		fmt.Printf("   Restored %d files into '%s'.\n", filesRestored, unpackOutput)
+		if filesFailed > 0 {
+			fmt.Printf("   Failed to restore %d files.\n", filesFailed)
+		}
+		if err != nil || filesFailed > 0 {
+			os.Exit(exitPartial)
+		}
+	},
+}
+
+// extractBundle restores the tar archive read from r under dir. Per-file problems (an existing
+// file, an unsafe path) are reported and counted; an error that corrupts the rest of the stream,
+// such as a failed authentication, stops the extraction and is returned. Directory permissions
+// and times are applied last, so read-only directories can still be filled.
+func extractBundle(r io.Reader, dir string) (restored, failed int, err error) {
+	type dirAttrs struct {
+		path    string
+		mode    os.FileMode
+		modTime time.Time
+	}
+	var dirs []dirAttrs
+
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return restored, failed, err
+		}
+		if !filepath.IsLocal(filepath.FromSlash(hdr.Name)) { // Rejects absolute paths and "..".
+			errorf("%s Refusing unsafe path '%s'\n", markFail, hdr.Name)
+			failed++
+			continue
+		}
+		target := filepath.Join(dir, filepath.FromSlash(hdr.Name))
+		mode := os.FileMode(hdr.Mode).Perm()
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0700); err != nil {
+				errorf("%s Failed to create '%s': %v\n", markFail, target, err)
+				failed++
+				continue
+			}
+			dirs = append(dirs, dirAttrs{target, mode, hdr.ModTime})
+		case tar.TypeReg:
+			if _, err := os.Lstat(target); err == nil {
+				errorf("%s '%s' already exists. Left unchanged.\n", markFail, target)
+				failed++
+				continue
+			}
+			if err := os.MkdirAll(filepath.Dir(target), 0700); err != nil {
+				errorf("%s Failed to create '%s': %v\n", markFail, filepath.Dir(target), err)
+				failed++
+				continue
+			}
+			if err := aegis.WritePlaintext(target, tr); err != nil {
+				return restored, failed, fmt.Errorf("%s: %v", hdr.Name, err) // The stream cannot be trusted past this point.
+			}
+			if err := os.Chmod(target, mode); err != nil {
+				warnf("Warning: could not restore permissions of '%s': %v\n", target, err)
+			}
+			if err := os.Chtimes(target, hdr.ModTime, hdr.ModTime); err != nil {
+				warnf("Warning: could not restore modification time of '%s': %v\n", target, err)
+			}
+			restored++
+			if !quiet {
+				fmt.Printf("%s Restored '%s'\n", markOK, target)
+			}
+		default:
+			warnf("Warning: skipping '%s' (unsupported entry type)\n", hdr.Name)
+		}
+	}
+
+	// The archive ends before the sealed stream does; reading the rest authenticates the final chunk.
+	if _, err := io.Copy(io.Discard, r); err != nil {
+		return restored, failed, err
+	}
+
+	for i := len(dirs) - 1; i >= 0; i-- { // Children before parents, so setting a time is not undone.
+		d := dirs[i]
+		if err := os.Chmod(d.path, d.mode); err != nil {
+			warnf("Warning: could not restore permissions of '%s': %v\n", d.path, err)
+		}
+		if err := os.Chtimes(d.path, d.modTime, d.modTime); err != nil {
+			warnf("Warning: could not restore modification time of '%s': %v\n", d.path, err)
+		}
+	}
+	return restored, failed, nil
+}
+
+func init() {
+	unpackCmd.Flags().StringVarP(&unpackOutput, "output", "o", "", "Directory to restore the bundle into (required; created if needed)")
+	unpackCmd.Flags().StringVar(&unpackPasswordFile, "password-file", "", "Read the password from the first line of this file")
+	RootCmd.AddCommand(unpackCmd)
+}
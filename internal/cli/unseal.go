@@ -1,172 +1,741 @@
 package cli
 
 import (
-	"crypto/aes"
-	"crypto/cipher"
+	"aegis/internal/crypto"
+	"aegis/pkg/aegis"
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
-
-	"golang.org/x/crypto/scrypt"
-	"golang.org/x/term"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/spf13/cobra"
 )
 
+// unsealPasswordFile holds the --password-file path; when set it takes precedence over other password sources.
+var unsealPasswordFile string
+
+// unsealPasswordStdin, when set via --password-stdin, reads the password from the first line piped to stdin.
+var unsealPasswordStdin bool
+
+// unsealKeyfile holds the --keyfile path, needed along with the password for files sealed with seal --keyfile.
+var unsealKeyfile string
+
+// unsealDryRun, when set via --dry-run, decrypts in memory to check the password but writes and deletes nothing.
+var unsealDryRun bool
+
+// unsealRetries holds --retries: how many times a wrong password may be re-entered at the prompt.
+var unsealRetries int
+
+// unsealRemoveSealed, when set via --remove-sealed, deletes each .aegis file once it has been restored.
+var unsealRemoveSealed bool
+
+// unsealForce, when set via --force, lets restored files replace existing files at the same path.
+var unsealForce bool
+
+// unsealJobs holds --jobs: how many files are decrypted at the same time.
+var unsealJobs int
+
+// unsealFollowSymlinks, when set via --follow-symlinks, walks into symlinked directories, as seal --follow-symlinks does.
+var unsealFollowSymlinks bool
+
+// unsealRecursive holds --recursive; when false, only the sealed files directly in the directory are unsealed.
+var unsealRecursive bool
+
+// unsealMaxDepth holds --max-depth: how many directory levels below the root are unsealed; 0 means no limit.
+var unsealMaxDepth int
+
+// unsealMatches holds the repeatable --match globs; when set, only sealed files whose original path matches one are restored.
+var unsealMatches []string
+
+// unsealOutput, when set via --output, restores plaintext under this directory instead of next to the sealed files.
+var unsealOutput string
+
+// unsealJSON, when set via --json, prints the final summary as one JSON object on stdout; everything else goes to stderr.
+var unsealJSON bool
+
 var unsealCmd = &cobra.Command{
 	Use:   "unseal [directory]",
 	Short: "Decrypt a directory",
 	Long:  `Unseal (decrypt) all files in a directory using the correct password.`,
 	Args:  cobra.MinimumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) { // The function executed when 'aegis unseal' is run.
-		dir := args[0] //Retrieves the directory path provided as the first argument.
+		dir := args[0]       //Retrieves the directory path provided as the first argument.
+		if dir == stdioArg { // Streams stdin to stdout; no directory is walked and nothing is removed.
+			unsealStdio(cmd)
+			return
+		}
+		var jsonOut io.Writer // The real stdout under --json; see jsonStdout.
+		if unsealJSON {
+			jsonOut = jsonStdout()
+		}
+		if unsealPasswordStdin {
+			if err := checkPasswordStdin(unsealPasswordFile); err != nil {
+				errorf("Error: %v\n", err)
+				os.Exit(exitFatal)
+			}
+		}
+		if unsealMaxDepth < 0 {
+			errorf("Error: --max-depth cannot be negative\n")
+			os.Exit(exitFatal)
+		}
+		walkDepth := unsealMaxDepth // The depth the walk covers; --recursive=false is a depth of 1.
+		if !unsealRecursive {
+			walkDepth = 1
+		}
+		if unsealJobs < 1 {
+			errorf("Error: --jobs must be at least 1\n")
+			os.Exit(exitFatal)
+		}
+		var matches *excludeMatcher // --match: same name-or-relative-path matching as seal --exclude, used to select.
+		if len(unsealMatches) > 0 {
+			for _, pattern := range unsealMatches {
+				if _, err := filepath.Match(filepath.ToSlash(pattern), ""); err != nil || pattern == "" {
+					errorf("Error: invalid --match pattern %q\n", pattern)
+					os.Exit(exitFatal)
+				}
+			}
+			matches, _ = newExcludeMatcher(unsealMatches)
+		}
 
-		fmt.Printf("🔑 Attempting to unseal files in directory '%s'...\n", dir)
-		fmt.Print("Enter password: ")
+		if unsealDryRun {
+			fmt.Printf("%s Dry run: checking which files would be unsealed in '%s'...\n", markDryRun, dir)
+		} else if !quiet {
+			fmt.Printf("%s Attempting to unseal files in directory '%s'...\n", markUnseal, dir)
+		}
+
+		keyfile, err := readKeyfile(unsealKeyfile)
+		if err != nil {
+			errorf("Error: %v\n", err)
+			os.Exit(exitFatal)
+		}
 
 		// --- PASSWORD ERROR HANDLING ---
-		pwdBytes, err := term.ReadPassword(int(os.Stdin.Fd())) // Reads password from STDIN without showing input.
-		if err != nil {                                        // Checks if reading the password failed.
-			// Prints error to standard error stream (os.Stderr) and exits cleanly
-			fmt.Fprintf(os.Stderr, "Error reading password: %v\n", err)
-			return // Exit Run function immediately
-		}
-		password := string(pwdBytes)
-		fmt.Println()
+		password, err := readPasswordFlags(unsealPasswordStdin, unsealPasswordFile, false, 0) // Reads password from --password-stdin, --password-file, AEGIS_PASSWORD, or the terminal.
+		if err != nil {                                                                       // Checks if reading the password failed.
+			// Prints error to standard error stream (os.Stderr) and exits with exitFatal
+			errorf("Error reading password: %v\n", err)
+			os.Exit(exitFatal) // Nothing was processed, so this is a fatal error.
+		}
 		// ---------------------------------------
+		started := time.Now() // Summary timing; starts after the password prompt so typing is not counted.
 
-		var filesUnsealed int // Counter for successfully unsealed files.
-		var filesFailed int   // Counter for files that failed to unseal.
-		var filesSkipped int  // Counter for files that were skipped.
-		// walkErr captures any fatal error from the directory walk.
+		keys := crypto.NewKeyfileKeyCache(password, keyfile) // Derives each distinct salt's key only once.
 
-		walkErr := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error { // Starts recursively walking the directory.
-			// If the walk encounters an error (like non-existent directory),
-			// we must return the error itself to the main walkErr variable and trigger the Fatal Error block at the end.
-			if err != nil {
-				return err // Returns error to walkErr to trigger the Fatal Error block at the end.
+		var outputAbs string // Absolute output root, so restored files inside the source tree are not revisited.
+		if unsealOutput != "" {
+			outputAbs, _ = filepath.Abs(unsealOutput)
+		}
+
+		// --- PASSWORD PRE-CHECK ---
+		// The password is checked against the first sealed files before anything is written, so a
+		// wrong one fails fast instead of failing every file. A password typed at the prompt may be
+		// re-entered up to --retries times; non-interactive passwords cannot be.
+		retriesLeft := 0
+		if !unsealPasswordStdin && passwordPrompted(unsealPasswordFile, passwordEnvVar) {
+			retriesLeft = unsealRetries
+		}
+		candidates, _ := firstSealedFiles(dir, outputAbs, walkDepth, 2) // Walk errors are reported by the main walk below.
+		for len(candidates) > 0 {
+			err := checkPassword(candidates, keys)
+			if errors.Is(err, crypto.ErrKeyfileRequired) { // Retrying the password cannot help.
+				errorf("%s '%s' was sealed with a key file; supply it with --keyfile. Nothing was unsealed.\n", markError, candidates[0])
+				os.Exit(exitFatal)
 			}
-			if info.IsDir() { // Skips directories, only processing files.
-				return nil
+			if !errors.Is(err, crypto.ErrDecryptFailed) || errors.Is(err, crypto.ErrCorrupted) { // Accepted, or a damaged file the walk will report.
+				break
 			}
-			// Skip files that do not have the .aegis extension
-			if !strings.HasSuffix(path, ".aegis") {
-				filesSkipped++
+			if retriesLeft == 0 {
+				if errors.Is(err, crypto.ErrWrongPassword) { // The key check proves it; no need to hedge.
+					errorf("%s Wrong password%s. Nothing was unsealed.\n", markError, orKeyfile(keyfile))
+				} else {
+					errorf("%s Wrong password%s (or '%s' is corrupted or renamed). Nothing was unsealed.\n", markError, orKeyfile(keyfile), candidates[0])
+				}
+				os.Exit(exitPartial) // Same code as per-file wrong-password failures, for scripts.
+			}
+			errorf("%s Wrong password%s (%d attempts left).\n", markError, orKeyfile(keyfile), retriesLeft)
+			retriesLeft--
+			if password, err = readPassword(unsealPasswordFile, false); err != nil {
+				errorf("Error reading password: %v\n", err)
+				os.Exit(exitFatal)
+			}
+			keys = crypto.NewKeyfileKeyCache(password, keyfile)
+		}
+		// ---------------------------------------
+
+		manifests := make(map[string]nameManifest) // Directory -> decrypted name manifest (nil if none).
+
+		// Seal manifest (--manifest): restored content is hashed on the fly and compared at the end.
+		recorded, err := readSealManifest(dir, keys)
+		if err != nil {
+			warnf("Warning: Could not read %s: %v. Completeness will not be checked.\n", sealManifestFile, err)
+		}
+		restoreRoot := dir // Root that manifest paths are relative to after unsealing.
+		if unsealOutput != "" {
+			restoreRoot = unsealOutput
+		}
+		restored := make(map[string]manifestEntry) // Restored relative path -> size and hash.
+		restoredFrom := make(map[string]string)    // Output path -> sealed file restored into it by this run.
+		var mu sync.Mutex                          // Guards the maps above and the name manifests once --jobs > 1.
+
+		var filesUnsealed atomic.Int64  // Counter for successfully unsealed files.
+		var failures failureList        // Files that failed to unseal, and why, for the closing report.
+		var filesSkipped atomic.Int64   // Counter for files that were skipped.
+		var filesExisting atomic.Int64  // Counter for files left sealed because their output already exists.
+		var filesUnmatched atomic.Int64 // Counter for files left sealed because they do not match --match.
+		var bytesRestored atomic.Int64  // Plaintext bytes restored (or, in a dry run, decrypted).
+		// collides reports (and counts) a second sealed file that would restore to the same path.
+		collides := func(path, out string, log *fileLog) bool {
+			mu.Lock()
+			defer mu.Unlock()
+			first, ok := restoredFrom[out]
+			if ok {
+				log.errorf("%s Cannot unseal %s: '%s' was already restored from %s. Skipping.\n", markFail, path, out, first)
+				failures.add(path, "'%s' was already restored from %s", out, first)
+				return true
+			}
+			restoredFrom[out] = path
+			return false
+		}
+		// exists reports (and counts) an output that would replace an existing file without --force.
+		exists := func(path, out string, log *fileLog) bool {
+			if unsealForce {
+				return false
+			}
+			if _, err := os.Lstat(out); err != nil {
+				return false
+			}
+			if unsealDryRun {
+				log.printf("   Would skip (output exists): %s -> %s\n", filepath.Base(path), out)
+			} else {
+				log.warnf("Warning: '%s' already exists; leaving %s sealed (use --force to overwrite)\n", out, path)
+			}
+			filesExisting.Add(1)
+			return true
+		}
+
+		// unsealFile restores one sealed file, queueing its messages on log. Per-file problems are
+		// reported and counted; only errors that should stop the whole run are returned.
+		unsealFile := func(path string, log *fileLog) error {
+			// Hidden names: the directory's manifest maps the token back to the original file name.
+			sealedDir := filepath.Dir(path)
+			mu.Lock()
+			names, loaded := manifests[sealedDir]
+			if !loaded {
+				var err error
+				names, err = readNameManifest(sealedDir, keys)
+				if err != nil {
+					log.warnf("Warning: Could not read name manifest in %s: %v\n", sealedDir, err)
+				}
+				manifests[sealedDir] = names
+			}
+			mu.Unlock()
+			originalName := names[filepath.Base(path)] // Empty when the file was sealed without --hide-names.
+
+			// --match: a file is selected by its sealed name, or by its original name, which is known
+			// up front for hidden names and otherwise once the header's extension is decrypted.
+			selected := func(name string) bool {
+				rel, _ := filepath.Rel(dir, filepath.Join(sealedDir, name))
+				return matches.match(rel, false)
+			}
+			unmatched := func() error {
+				log.verbosef("   Skipping (does not match --match): %s\n", path)
+				filesUnmatched.Add(1)
 				return nil
 			}
+			pending := matches != nil && !selected(filepath.Base(path)) // Still to be matched by the original name.
+			if pending && originalName != "" {
+				if !selected(originalName) {
+					return unmatched()
+				}
+				pending = false
+			}
 
-			data, err := os.ReadFile(path) // Reads the entire sealed file into memory.
-			if err != nil {                // Checks if reading the file failed.
-				fmt.Printf("❌ Could not read sealed file %s: %v. Skipping.\n", path, err) // Prints error message for the specific file.
-				filesFailed++                                                             // Increments failed counter.
-				return nil                                                                // Skip to the next file
+			f, err := os.Open(path) // Opens the sealed file; chunked files are decrypted as a stream.
+			if err != nil {         // Checks if opening the file failed.
+				log.errorf("%s Could not read sealed file %s: %v. Skipping.\n", markFail, path, err) // Prints error message for the specific file.
+				failures.add(path, "could not read: %v", err)                                        // Records the failure for the closing report.
+				return nil                                                                           // Skip to the next file
 			}
+			defer f.Close() // Closes the sealed file once this entry is processed.
 
-			// Decryption Setup
-			if len(data) < 16+12 { // Minimum length: 16 bytes salt + 12 bytes nonce(GCM is usually 12B).
-				fmt.Printf("❌ Sealed file %s is too short/corrupted. Skipping.\n", path) // Prints error for malformed file.
-				filesFailed++                                                            // Increments failed counter.
-				return nil                                                               // Skip to the next file
+			// Header validation, key derivation and metadata decryption.
+			payload, err := crypto.Open(f, filepath.Base(path), keys)
+			if err != nil { // Distinguishes authentication failures from malformed framing.
+				switch {
+				case errors.Is(err, crypto.ErrDecryptFailed):
+					log.errorf("%s Decryption FAILED for '%s': %s.\n", markError, path, decryptFailure(err, keyfile)) // Prints decryption failure message.
+					failures.add(path, "decryption failed: %s", decryptFailure(err, keyfile))
+				case errors.Is(err, crypto.ErrKeyfileRequired): // A tree sealed partly with --keyfile.
+					log.errorf("%s '%s' was sealed with a key file; supply it with --keyfile. Skipping.\n", markError, path)
+					failures.add(path, "sealed with a key file; supply it with --keyfile")
+				default:
+					log.errorf("%s Sealed file %s is malformed (%v). Skipping.\n", markFail, path, err) // Prints error for malformed file.
+					failures.add(path, "malformed: %v", err)
+				}
+				return nil // Skip to the next file
 			}
 
-			salt := data[:16] // Extracts the salt from the start of the file.(used for key derivation).
-			//Re-derive the key using Scrypt with the stored salt and the user's password.
-			key, err := scrypt.Key([]byte(password), salt, 1<<15, 8, 1, 32) // Derives the encryption key using Scrypt.
-			if err != nil {                                                 // Checks if key derivation failed.
-				fmt.Printf("❌ Failed to derive key for %s: %v. Skipping.\n", path, err) // Prints error message.
-				filesFailed++                                                           // Increments failed counter.
-				return nil                                                              // Skip to the next file
+			log.debugf("%s: format v%d, scrypt N=%d r=%d p=%d, compressed=%v\n", path, payload.Version, payload.KDF.N, payload.KDF.R, payload.KDF.P, payload.Compressed)
+
+			stem := strings.TrimSuffix(filepath.Base(path), ".aegis")        // Empty for dotfiles sealed as ".aegis" by earlier versions.
+			restoredName := aegis.RestoredName(filepath.Base(path), payload) // The recorded name (v8+), or the stem with the recovered extension.
+			switch {
+			case originalName != "": // Hidden names restore the full original name from the manifest.
+				restoredName = originalName
+			case payload.Name != "" && restoredName != payload.Name:
+				log.warnf("Warning: '%s' records an unusable original name %q; restoring it as '%s'\n", path, payload.Name, restoredName)
 			}
-			//initialize AES-GCM for decryption
-			block, err := aes.NewCipher(key) // Creates a new AES cipher block with the derived key.
-			if err != nil {                  // Checks if cipher creation failed.
-				filesFailed++                                                            // Increments failed counter.
-				return fmt.Errorf("failed to create cipher block for %s: %v", path, err) // Returns error to be handled by walkErr.
+			if pending && !selected(restoredName) { // Only the metadata has been decrypted so far.
+				return unmatched()
 			}
 
-			gcm, err := cipher.NewGCM(block) // Sets up Galois/Counter Mode (GCM) for authenticated decryption.
-			if err != nil {                  // Checks if GCM setup failed.
-				filesFailed++                                                   // Increments failed counter.
-				return fmt.Errorf("failed to create GCM for %s: %v", path, err) // Returns error to be handled by walkErr.
+			// Construct the output location: next to the sealed file, or mirrored under --output. Only the
+			// base name is rewritten, so dots in directory names (a.b/c.aegis) never affect the result.
+			outDir := filepath.Dir(path)
+			if unsealOutput != "" {
+				rel, err := filepath.Rel(dir, outDir)
+				if err != nil {
+					return fmt.Errorf("failed to resolve relative path for %s: %v", path, err)
+				}
+				outDir = filepath.Join(unsealOutput, rel)
+				if !unsealDryRun {
+					if err := os.MkdirAll(outDir, 0700); err != nil {
+						return fmt.Errorf("failed to create output directory %s: %v", outDir, err)
+					}
+				}
 			}
-			//seperate nonce and ciphertext
-			nonceSize := gcm.NonceSize() // Retrieves the nonce size required by GCM.
 
-			if len(data) < 16+nonceSize { // Validates that the file is long enough to contain salt + nonce + ciphertext.
-				fmt.Printf("❌ Sealed file %s is malformed (missing nonce/ciphertext). Skipping.\n", path) // Prints error for malformed file.
-				filesFailed++                                                                             //	 Increments failed counter.
-				return nil                                                                                // Skip to the next file
+			if !payload.HasExt { // Null terminator not found
+				log.warnf("Warning: Could not find original extension in '%s'. Assuming old format or corruption (see 'aegis recover --ext').\n", path) // Prints warning message.
+				if stem == "" {                                                                                                                         // Nothing is left to name the output after.
+					log.errorf("%s Cannot unseal %s: no file name to restore. Skipping.\n", markFail, path)
+					failures.add(path, "no file name to restore")
+					return nil
+				}
+				out := filepath.Join(outDir, stem) // Output filename is the stem without any extension.
+				if collides(path, out, log) || exists(path, out, log) {
+					return nil
+				}
+				failures.add(path, "no recorded extension; restored as '%s' without one", out) // Counted as failed: the content may be incomplete.
+				if unsealDryRun {                                                              // Reports the planned output without writing it.
+					log.println("Would unseal (Warning):", out)
+					return nil
+				}
+				content := newHashingReader(payload.Content)
+				if err := aegis.WritePlaintext(out, content); err != nil { // Writes the decrypted data as-is (no extension).
+					log.errorf("%s Failed to write unsealed file %s: %v. Skipping.\n", markFail, out, err)
+					return nil
+				}
+				bytesRestored.Add(content.size)
+				if unsealRemoveSealed {
+					os.Remove(path) // Deletes the original sealed file.
+				}
+				if !quiet {
+					log.println("Unsealed (Warning):", out) // Prints success message with warning.
+				}
+				return nil // Skip to the next file
 			}
 
-			nonce := data[16 : 16+nonceSize]  // Extracts the nonce from the file data.
-			ciphertext := data[16+nonceSize:] //
+			out := filepath.Join(outDir, restoredName)
+			if collides(path, out, log) || exists(path, out, log) { // Collisions: e.g. a hidden-name token and a plain .aegis file restoring the same name.
+				return nil
+			}
 
-			plaintextWithExt, err := gcm.Open(nil, nonce, ciphertext, nil) // Decrypts the ciphertext using AES-GCM.
-			if err != nil {                                                // Checks if decryption failed (likely due to wrong password or corruption).
-				fmt.Printf("⛔ Decryption FAILED for '%s': Wrong password or file corrupted.\n", filepath.Base(path)) // Prints decryption failure message.
-				filesFailed++                                                                                        // Increments failed counter.
-				return nil                                                                                           // Skip to the next file
+			content := newHashingReader(payload.Content)
+			recordRestored := func() {
+				if rel, err := filepath.Rel(restoreRoot, out); err == nil {
+					mu.Lock()
+					restored[filepath.ToSlash(rel)] = content.entry()
+					mu.Unlock()
+				}
 			}
 
-			// --- FILENAMELOGIC: Recover Extension ---
-			nullIndex := -1                      // Index of the null terminator separating extension and content.
-			for i, b := range plaintextWithExt { // Scans for the null terminator byte (0x00).
-				if b == 0x00 { // Found the null terminator
-					nullIndex = i // Sets the index and
-					break         // exits the loop
+			if unsealDryRun { // Every chunk is still authenticated; the plaintext is discarded.
+				if _, err := io.Copy(io.Discard, content); err != nil {
+					log.errorf("%s Decryption FAILED for '%s': %v.\n", markError, path, err)
+					failures.add(path, "decryption failed: %v", err)
+					return nil
 				}
+				filesUnsealed.Add(1)
+				bytesRestored.Add(content.size)
+				recordRestored()
+				log.printf("   Would unseal '%s' -> '%s'\n", filepath.Base(path), out)
+				return nil
 			}
 
-			if nullIndex == -1 { // Null terminator not found
-				fmt.Printf("Warning: Could not find original extension in '%s'. Assuming old format or corruption.\n", filepath.Base(path)) // Prints warning message.
-				out := strings.TrimSuffix(path, ".aegis")                                                                                   // Constructs output filename by removing .aegis extension.
-				os.WriteFile(out, plaintextWithExt, 0600)                                                                                   // Writes the decrypted data as-is (no extension).
-				os.Remove(path)                                                                                                             // Deletes the original sealed file.
-				filesFailed++                                                                                                               //	Increments failed counter.
-				fmt.Println("Unsealed (Warning):", out)                                                                                     // Prints success message with warning.
-				return nil                                                                                                                  // Skip to the next file
+			if err := aegis.WritePlaintext(out, content); err != nil { // Streams the decrypted plaintext to the new file.
+				log.errorf("%s Failed to write unsealed file %s: %v. Skipping.\n", markFail, out, err) // Prints error message.
+				failures.add(path, "could not write '%s': %v", out, err)                               // Records the failure for the closing report.
+				return nil                                                                             // Skip to the next file
+			}
+			if err := os.Chmod(out, payload.Mode); err != nil { // Restores the original permissions (umask doesn't apply to Chmod).
+				log.warnf("Warning: Failed to restore permissions on %s: %v\n", out, err)
+			}
+			if !payload.ModTime.IsZero() { // Restores the original modification time when the format carries it.
+				if err := os.Chtimes(out, payload.ModTime, payload.ModTime); err != nil {
+					log.warnf("Warning: Failed to restore modification time on %s: %v\n", out, err)
+				}
 			}
 
-			originalExt := string(plaintextWithExt[:nullIndex]) // Extracts the original file extension.
-			plaintext := plaintextWithExt[nullIndex+1:]         // Extracts the actual plaintext data.
-			// Construct output filename by appending the original extension
+			if unsealRemoveSealed { // Sealed files are kept unless removal was asked for.
+				if err := os.Remove(path); err != nil { // Deletes the original sealed file.
+					log.warnf("Warning: Failed to remove sealed file %s: %v\n", path, err) // Warns if deletion fails.
+				}
+			}
 
-			base := strings.TrimSuffix(path, ".aegis") // Base filename without .aegis extension
-			out := base + originalExt                  // Joins base with the recovered original extension
+			filesUnsealed.Add(1) // Increments success counter.
+			bytesRestored.Add(content.size)
+			recordRestored()
+			if !quiet { // --quiet leaves only errors and the summary.
+				log.printColorf(colorGreen, "%s Unsealed '%s' -> '%s'\n", markOK, filepath.Base(path), out) // Prints success message.
+			}
+			return nil // Continues to the next file
+		}
 
-			if err := os.WriteFile(out, plaintext, 0600); err != nil { // Writes the decrypted plaintext to the new file.
-				fmt.Printf("❌ Failed to write unsealed file %s: %v. Skipping.\n", out, err) // Prints error message.
-				filesFailed++                                                               // Increments failed counter.
-				return nil                                                                  // Skip to the next file
+		// Progress line (interactive terminals only): the files to visit are counted up front.
+		bar := newProgress(countFiles(dir, func(path string) bool {
+			if rel, _ := filepath.Rel(dir, path); beyondMaxDepth(rel, walkDepth) {
+				return true
 			}
+			abs, _ := filepath.Abs(path)
+			return outputAbs != "" && abs == outputAbs
+		}))
 
-			if err := os.Remove(path); err != nil { // Deletes the original sealed file.
-				fmt.Printf("Warning: Failed to remove sealed file %s: %v\n", path, err) // Warns if deletion fails.
+		// Worker pool (--jobs): the walk stays serial and hands sealed files to up to --jobs
+		// goroutines. The first fatal error from a worker stops further files from being queued.
+		var workers sync.WaitGroup
+		slots := make(chan struct{}, unsealJobs)
+		var workerErr error // Guarded by mu.
+		failWorker := func(err error) {
+			mu.Lock()
+			if workerErr == nil {
+				workerErr = err
+			}
+			mu.Unlock()
+		}
+
+		ctx, release := interruptContext() // Ctrl+C stops queuing files; files in progress are finished.
+		defer release()
+
+		visitedDirs := make(map[string]bool)                            // Resolved directories already walked (--follow-symlinks), so link loops end.
+		var walkFn filepath.WalkFunc                                    // Declared before it is assigned so linked directories can be walked with it.
+		walkFn = func(path string, info os.FileInfo, err error) error { // Walks the directory recursively.
+			// If the walk encounters an error (like non-existent directory),
+			// we must return the error itself to the main walkErr variable and trigger the Fatal Error block at the end.
+			if err != nil {
+				return err // Returns error to walkErr to trigger the Fatal Error block at the end.
+			}
+			if err := ctx.Err(); err != nil { // Interrupted between files.
+				return err
+			}
+			if info.IsDir() { // Skips directories, only processing files.
+				if !unsealRecursive && path != dir {
+					bar.report(false, func() { verbosef("   Skipping (subdirectory, --recursive=false): %s\n", path) })
+					return filepath.SkipDir
+				}
+				if rel, _ := filepath.Rel(dir, path); beyondMaxDepth(rel, unsealMaxDepth) {
+					bar.report(false, func() { verbosef("   Skipping (deeper than --max-depth): %s\n", path) })
+					return filepath.SkipDir
+				}
+				if outputAbs != "" {
+					if abs, _ := filepath.Abs(path); abs == outputAbs {
+						bar.report(false, func() { verbosef("   Skipping (output directory): %s\n", path) })
+						return filepath.SkipDir // Never descends into the restore target.
+					}
+				}
+				if unsealFollowSymlinks {
+					if real, err := filepath.EvalSymlinks(path); err == nil {
+						if visitedDirs[real] {
+							bar.report(false, func() { verbosef("   Skipping (directory already visited through a symlink): %s\n", path) })
+							return filepath.SkipDir
+						}
+						visitedDirs[real] = true
+					}
+				}
+				return nil
+			}
+			if unsealFollowSymlinks && info.Mode()&os.ModeSymlink != 0 {
+				if target, err := os.Stat(path); err == nil && target.IsDir() { // Walks the linked directory under the link's own path.
+					bar.report(true, nil)
+					return filepath.Walk(path+string(filepath.Separator), walkFn)
+				}
+			}
+			if info.Name() == namesManifestFile { // Name manifests are consumed alongside the files they describe.
+				bar.report(true, nil)
+				return nil
+			}
+			if path == filepath.Join(dir, sealManifestFile) { // Read up front and checked after the walk.
+				bar.report(true, nil)
+				return nil
+			}
+			if path == filepath.Join(dir, checksumManifestFile) { // Describes the sealed files, not their contents.
+				bar.report(true, nil)
+				return nil
+			}
+			// Skip files that do not have the .aegis extension
+			if !strings.HasSuffix(path, ".aegis") {
+				bar.report(true, func() { verbosef("   Skipping (not sealed): %s\n", path) })
+				filesSkipped.Add(1)
+				return nil
 			}
 
-			filesUnsealed++                                                   // Increments success counter.
-			fmt.Printf("✅ Unsealed '%s' -> '%s'\n", filepath.Base(path), out) // Prints success message.
-			return nil                                                        // Continues to the next file
-		})
+			run := func() error {
+				var log fileLog
+				err := unsealFile(path, &log)
+				bar.report(true, log.flush) // One file's messages are printed together.
+				return err
+			}
+			if unsealJobs == 1 { // Serial: no goroutines, same order as the walk.
+				return run()
+			}
+			mu.Lock()
+			stop := workerErr
+			mu.Unlock()
+			if stop != nil {
+				return stop
+			}
+			slots <- struct{}{} // Waits for a free worker.
+			workers.Add(1)
+			go func() {
+				defer workers.Done()
+				defer func() { <-slots }()
+				if err := run(); err != nil {
+					failWorker(err)
+				}
+			}()
+			return nil
+		}
+		walkErr := filepath.Walk(dir, walkFn)
+		workers.Wait()
+		if walkErr == nil {
+			walkErr = workerErr
+		}
+		bar.clear()
+		interrupted := errors.Is(walkErr, context.Canceled)
+		if interrupted {
+			walkErr = nil
+		}
 
 		if walkErr != nil { // Checks if a fatal error occurred during the directory walk.
-			fmt.Printf("\n\n🔥 Fatal Error during unsealing: %v\n", walkErr) // Prints the fatal error message.
-			os.Exit(1)                                                      // Exits the program with a non-zero status code.
+			errorf("\n\n%s Fatal Error during unsealing: %v\n", markFatal, walkErr) // Prints the fatal error message.
+			os.Exit(exitFatal)                                                      // Exits the program with a non-zero status code.
+		}
+
+		if !unsealDryRun && unsealRemoveSealed { // Removes name manifests once every file they describe is restored.
+			removeSpentManifests(manifests)
+		}
+
+		dirsCreated := 0
+		complete := matches == nil && walkDepth == 0 && !interrupted // The whole tree was restored, so the manifest applies to it.
+		if recorded != nil && complete {                             // Directory layout from seal --include-empty-dirs; not part of a --match selection.
+			dirsCreated = restoreDirs(recorded.Dirs, restoreRoot, restored, unsealDryRun)
+		}
+
+		manifestProblems := 0
+		checkFiles := recorded != nil && len(recorded.Files) > 0 && complete // A manifest may hold only directories; --match or an interruption restores only part of it.
+		if checkFiles {
+			manifestProblems = checkSealManifest(recorded, restored, restoreRoot)
+		}
+		if recorded != nil && complete {
+			if !unsealDryRun && unsealRemoveSealed && manifestProblems == 0 && failures.count() == 0 && filesExisting.Load() == 0 { // Spent once everything it lists is back.
+				if err := os.Remove(filepath.Join(dir, sealManifestFile)); err != nil {
+					warnf("Warning: Failed to remove %s: %v\n", sealManifestFile, err)
+				}
+			}
+		}
+
+		if complete && !unsealDryRun && unsealRemoveSealed && failures.count() == 0 && filesExisting.Load() == 0 { // No sealed file is left for it to describe.
+			if err := os.Remove(filepath.Join(dir, checksumManifestFile)); err != nil && !os.IsNotExist(err) {
+				warnf("Warning: Failed to remove %s: %v\n", checksumManifestFile, err)
+			}
+		}
+
+		if unsealJSON { // Replaces the summary below; the exit code is the same.
+			printJSONSummary(jsonOut, unsealSummary{Unsealed: filesUnsealed.Load(), runSummary: runSummary{
+				Skipped: filesSkipped.Load(), Failed: failures.count(), Bytes: bytesRestored.Load(), Duration: time.Since(started).Seconds(),
+				Directory: dir, DryRun: unsealDryRun, Interrupted: interrupted, Failures: failures.sorted(),
+			}, Existing: filesExisting.Load(), Unmatched: filesUnmatched.Load(), ManifestProblems: manifestProblems})
+			unsealExit(interrupted, failures.count() > 0 || manifestProblems > 0)
+			return
 		}
 
 		// Final summary output
-		fmt.Printf("\n✨ Unsealing complete for directory '%s'.\n", dir)   // Prints completion message.
-		fmt.Printf("   Successfully unsealed %d files.\n", filesUnsealed) // Prints count of successfully unsealed files.
-		if filesFailed > 0 {                                              // Prints failed count only if necessary.
-			fmt.Printf("   Failed to unseal %d files (wrong password, corruption, or old format).\n", filesFailed) // Prints count of failed files.
+		switch {
+		case interrupted:
+			fmt.Printf("\n%s Unsealing interrupted in directory '%s'; the remaining files are still sealed.\n", markStop, dir)
+			fmt.Printf("   Unsealed %d files (%s) before stopping.\n", filesUnsealed.Load(), formatSize(bytesRestored.Load()))
+		case unsealDryRun:
+			fmt.Printf("\n%s Dry run complete for directory '%s'.\n", markDryRun, dir)
+			fmt.Printf("   Would unseal %d files (%s).\n", filesUnsealed.Load(), formatSize(bytesRestored.Load())) // Prints count of files that decrypted successfully.
+		default:
+			fmt.Printf("\n%s Unsealing complete for directory '%s'.\n", markDone, dir)                                                                                // Prints completion message.
+			fmt.Printf("   Successfully unsealed %d files (%s) in %s.\n", filesUnsealed.Load(), formatSize(bytesRestored.Load()), formatElapsed(time.Since(started))) // Prints count of successfully unsealed files.
+		}
+		if failures.count() > 0 { // Prints failed count only if necessary.
+			printColorf(colorRed, "   Failed to unseal %d files (wrong password, corruption, or old format).\n", failures.count()) // Prints count of failed files.
 		}
-		if filesSkipped > 0 { // Prints skipped count only if necessary.
-			fmt.Printf("   Skipped %d files (did not have '.aegis' extension).\n", filesSkipped) // Prints count of skipped files.
+		if filesSkipped.Load() > 0 { // Prints skipped count only if necessary.
+			fmt.Printf("   Skipped %d files (did not have '.aegis' extension).\n", filesSkipped.Load()) // Prints count of skipped files.
 		}
+		if filesExisting.Load() > 0 {
+			verb := "Left"
+			if unsealDryRun {
+				verb = "Would leave"
+			}
+			fmt.Printf("   %s %d files sealed because their output already exists (use --force to overwrite).\n", verb, filesExisting.Load())
+		}
+		if filesUnmatched.Load() > 0 {
+			verb := "Left"
+			if unsealDryRun {
+				verb = "Would leave"
+			}
+			fmt.Printf("   %s %d files sealed because they do not match --match.\n", verb, filesUnmatched.Load())
+		}
+		if dirsCreated > 0 {
+			verb := "Recreated"
+			if unsealDryRun {
+				verb = "Would recreate"
+			}
+			fmt.Printf("   %s %d empty directories.\n", verb, dirsCreated)
+		}
+		if checkFiles {
+			if manifestProblems == 0 {
+				fmt.Printf("   Manifest: all %d listed files accounted for.\n", len(recorded.Files))
+			} else {
+				fmt.Printf("   Manifest: %d of %d listed files missing or different.\n", manifestProblems, len(recorded.Files))
+			}
+		}
+		failures.print() // Each failure with its reason, below the counts.
+		unsealExit(interrupted, failures.count() > 0 || manifestProblems > 0)
 	},
 }
 
+// unsealExit exits with the code for an unseal run that got through the walk: interrupted, or
+// with failures. It returns (for exit code 0) otherwise.
+func unsealExit(interrupted, failed bool) {
+	switch {
+	case interrupted:
+		os.Exit(exitInterrupted)
+	case failed: // Distinct from exitFatal so scripts can detect a wrong password or corruption.
+		os.Exit(exitPartial)
+	}
+}
+
+// firstSealedFiles returns up to n .aegis files under dir in walk order, skipping the output
+// tree at skipAbs (if any), and the subdirectories beyond maxDepth levels (0 for no limit).
+func firstSealedFiles(dir, skipAbs string, maxDepth int, n int) ([]string, error) {
+	var found []string
+	errFound := errors.New("found") // Stops the walk early.
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if rel, _ := filepath.Rel(dir, path); beyondMaxDepth(rel, maxDepth) {
+				return filepath.SkipDir
+			}
+			if skipAbs != "" {
+				if abs, _ := filepath.Abs(path); abs == skipAbs {
+					return filepath.SkipDir
+				}
+			}
+			return nil
+		}
+		if strings.HasSuffix(path, ".aegis") {
+			found = append(found, path)
+			if len(found) == n {
+				return errFound
+			}
+		}
+		return nil
+	})
+	if err == errFound {
+		err = nil
+	}
+	return found, err
+}
+
+// orKeyfile names the key file next to the password in failure messages when one was given.
+func orKeyfile(keyfile []byte) string {
+	if keyfile == nil {
+		return ""
+	}
+	return " or key file"
+}
+
+// decryptFailure says why a sealed file failed to decrypt, as precisely as its format allows:
+// files from v9 on tell a wrong password from a damaged file, older ones cannot.
+func decryptFailure(err error, keyfile []byte) string {
+	switch {
+	case errors.Is(err, crypto.ErrWrongPassword):
+		return "wrong password" + orKeyfile(keyfile)
+	case errors.Is(err, crypto.ErrCorrupted):
+		return "file corrupted or renamed (the password is correct)"
+	}
+	return "wrong password" + orKeyfile(keyfile) + ", file corrupted, or file renamed"
+}
+
+// checkPassword authenticates the header and metadata of the first candidate. If it fails to
+// decrypt, the second candidate is tried so that one corrupted file does not look like a wrong
+// password; crypto.ErrDecryptFailed is returned only when every candidate fails. A candidate
+// that fails with crypto.ErrCorrupted has already proven the password and ends the check.
+func checkPassword(candidates []string, keys *crypto.KeyCache) error {
+	var err error
+	for _, path := range candidates {
+		var f *os.File
+		if f, err = os.Open(path); err != nil {
+			return err
+		}
+		debugf("checking the password against %s\n", path)
+		_, err = crypto.Open(f, filepath.Base(path), keys) // Decrypts only the first chunk.
+		f.Close()
+		if !errors.Is(err, crypto.ErrDecryptFailed) || errors.Is(err, crypto.ErrCorrupted) {
+			return err
+		}
+	}
+	return err
+}
+
+// removeSpentManifests deletes each name manifest whose listed sealed files no longer exist.
+func removeSpentManifests(manifests map[string]nameManifest) {
+	for dir, names := range manifests {
+		if len(names) == 0 {
+			continue
+		}
+		spent := true
+		for token := range names {
+			if _, err := os.Stat(filepath.Join(dir, token)); err == nil {
+				spent = false
+				break
+			}
+		}
+		if spent {
+			os.Remove(filepath.Join(dir, namesManifestFile))
+		}
+	}
+}
+
 func init() {
+	unsealCmd.Flags().BoolVar(&unsealJSON, "json", false, "Print the final summary as a JSON object on stdout (unsealed, skipped, failed, bytes, duration, failures); other output goes to stderr")
+	unsealCmd.Flags().StringVarP(&unsealOutput, "output", "o", "", "Restore files under this directory, mirroring the sealed tree")
+	unsealCmd.Flags().StringArrayVar(&unsealMatches, "match", nil, "Only restore sealed files whose original name or relative path matches this glob (repeatable)")
+	unsealCmd.Flags().StringVar(&unsealKeyfile, "keyfile", "", "Key file for files sealed with seal --keyfile (files sealed without one still unseal)")
+	unsealCmd.Flags().BoolVar(&unsealDryRun, "dry-run", false, "Verify the password and show output filenames without writing or deleting anything")
+	unsealCmd.Flags().BoolVar(&unsealRemoveSealed, "remove-sealed", false, "Delete each .aegis file after it has been restored and authenticated (kept by default)")
+	unsealCmd.Flags().BoolVar(&unsealRecursive, "recursive", true, "Unseal files in subdirectories too; --recursive=false unseals only the files directly in the directory")
+	unsealCmd.Flags().IntVar(&unsealMaxDepth, "max-depth", 0, "Unseal files at most this many levels below the directory (1 is the directory's own files); 0 means no limit")
+	unsealCmd.Flags().BoolVar(&unsealFollowSymlinks, "follow-symlinks", false, "Also unseal files in symlinked directories (for trees sealed with --follow-symlinks)")
+	unsealCmd.Flags().BoolVar(&unsealForce, "force", false, "Overwrite existing files at the restored paths instead of leaving those files sealed")
+	unsealCmd.Flags().IntVarP(&unsealJobs, "jobs", "j", 1, "Decrypt this many files in parallel")
+	unsealCmd.Flags().StringVar(&unsealStdioName, "name", defaultStdioName+".aegis", "With '-': the sealed file name the input was bound to when sealed")
+	unsealCmd.Flags().IntVar(&unsealRetries, "retries", 3, "Times a wrong password may be re-entered at the prompt before giving up (0 disables)")
+	unsealCmd.Flags().BoolVar(&unsealPasswordStdin, "password-stdin", false, "Read the password from the first line of stdin")
+	unsealCmd.Flags().StringVar(&unsealPasswordFile, "password-file", "", "Read the password from the first line of this file")
 	RootCmd.AddCommand(unsealCmd)
 }
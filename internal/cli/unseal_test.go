@@ -0,0 +1,43 @@
+package cli
+
+import (
+	"aegis/pkg/aegis"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestUnsealOutputDottedDirectory(t *testing.T) {
+	src := t.TempDir()
+	if err := os.Mkdir(filepath.Join(src, "a.b"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(src, "a.b", "c"), []byte("secret"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	kdf := aegis.KDFParams{N: 1 << 10, R: 8, P: 1, KeyLen: 32}
+	if _, err := aegis.SealDir(context.Background(), src, "pw", aegis.SealOptions{KDF: kdf}); err != nil {
+		t.Fatalf("SealDir: %v", err)
+	}
+
+	out := filepath.Join(t.TempDir(), "restored")
+	t.Setenv(passwordEnvVar, "pw")
+	RootCmd.SetArgs([]string{"unseal", "--quiet", "--output", out, src})
+	if err := RootCmd.Execute(); err != nil {
+		t.Fatalf("unseal: %v", err)
+	}
+
+	got, err := os.ReadFile(filepath.Join(out, "a.b", "c"))
+	if err != nil {
+		t.Fatalf("restored file: %v", err)
+	}
+	if string(got) != "secret" {
+		t.Errorf("content = %q, want %q", got, "secret")
+	}
+	for _, wrong := range []string{filepath.Join(out, "a.b", "c."), filepath.Join(out, "a")} {
+		if _, err := os.Lstat(wrong); err == nil {
+			t.Errorf("unexpected file %s", wrong)
+		}
+	}
+}
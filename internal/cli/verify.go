@@ -0,0 +1,141 @@
+package cli
+
+import (
+	"aegis/internal/crypto"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// verifyPasswordFile holds the --password-file path; when set it takes precedence over other password sources.
+var verifyPasswordFile string
+
+// verifyKeyfile holds the --keyfile path, needed for files sealed with seal --keyfile.
+var verifyKeyfile string
+
+// verifyManifest, when set via --manifest, checks the sealed files against aegis.checksums instead of decrypting them.
+var verifyManifest bool
+
+var verifyCmd = &cobra.Command{
+	Use:   "verify [directory]",
+	Short: "Check the integrity of sealed files",
+	Long: `Verify that every .aegis file in a directory decrypts and authenticates with the given password, without writing plaintext or removing anything.
+With --manifest, the sealed files are instead hashed and compared with the signed ` + checksumManifestFile + ` written by
+seal --checksum-manifest, which also reports sealed files that were deleted, added or swapped.`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		dir := args[0]
+
+		if !quiet {
+			fmt.Printf("%s Verifying sealed files in directory '%s'...\n", markVerify, dir)
+		}
+
+		keyfile, err := readKeyfile(verifyKeyfile)
+		if err != nil {
+			errorf("Error: %v\n", err)
+			os.Exit(exitFatal)
+		}
+		password, err := readPassword(verifyPasswordFile, false)
+		if err != nil {
+			errorf("Error reading password: %v\n", err)
+			os.Exit(exitFatal)
+		}
+		if verifyManifest { // The checksums are signed with the password alone.
+			verifyAgainstChecksums(dir, password)
+			return
+		}
+		keys := crypto.NewKeyfileKeyCache(password, keyfile)
+
+		var filesOK int     // Counter for files that authenticated successfully.
+		var filesFailed int // Counter for files that failed the integrity check.
+		walkErr := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if info.IsDir() || !strings.HasSuffix(path, ".aegis") {
+				return nil
+			}
+
+			if err := verifySealedFile(path, keys); err != nil {
+				if errors.Is(err, crypto.ErrDecryptFailed) && !errors.Is(err, crypto.ErrWrongPassword) && !errors.Is(err, crypto.ErrCorrupted) { // v9+ errors already say which.
+					errorf("%s FAILED '%s': %s (%v)\n", markError, path, decryptFailure(err, keyfile), err)
+				} else {
+					errorf("%s FAILED '%s': %v\n", markError, path, err)
+				}
+				filesFailed++
+				return nil
+			}
+			filesOK++
+			if !quiet {
+				fmt.Printf("%s OK '%s'\n", markOK, path)
+			}
+			return nil
+		})
+		if walkErr != nil {
+			errorf("\n\n%s Fatal Error during verification: %v\n", markFatal, walkErr)
+			os.Exit(exitFatal)
+		}
+
+		fmt.Printf("\n%s Verification complete for directory '%s'.\n", markDone, dir)
+		fmt.Printf("   Verified %d files OK, %d failed integrity.\n", filesOK, filesFailed)
+		if filesFailed > 0 {
+			os.Exit(exitPartial)
+		}
+	},
+}
+
+// verifyAgainstChecksums implements --manifest: the manifest's HMAC is checked with the password,
+// then every sealed file under dir is compared with it. Contents are not decrypted.
+func verifyAgainstChecksums(dir, password string) {
+	m, err := readChecksumManifest(dir, password)
+	switch {
+	case errors.Is(err, errChecksumMismatch):
+		errorf("%s %s does not authenticate: wrong password, or the manifest was modified\n", markError, checksumManifestFile)
+		os.Exit(exitPartial)
+	case err != nil:
+		errorf("Error: could not read %s: %v\n", checksumManifestFile, err)
+		os.Exit(exitFatal)
+	case m == nil:
+		errorf("Error: '%s' has no %s (written by seal --checksum-manifest)\n", dir, checksumManifestFile)
+		os.Exit(exitFatal)
+	}
+
+	checked, problems, err := verifyChecksums(dir, m)
+	if err != nil {
+		errorf("\n\n%s Fatal Error during verification: %v\n", markFatal, err)
+		os.Exit(exitFatal)
+	}
+	fmt.Printf("\n%s Verification complete for directory '%s' (against %s, updated %s).\n", markDone, dir, checksumManifestFile, m.Updated.Local().Format("2006-01-02 15:04:05"))
+	fmt.Printf("   Checked %d of %d listed files, %d problems.\n", checked, len(m.Files), problems)
+	if problems > 0 {
+		os.Exit(exitPartial)
+	}
+}
+
+// verifySealedFile decrypts a sealed file into a discarded buffer, authenticating every chunk.
+func verifySealedFile(path string, keys *crypto.KeyCache) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	payload, err := crypto.Open(f, filepath.Base(path), keys)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(io.Discard, payload.Content)
+	return err
+}
+
+func init() {
+	verifyCmd.Flags().StringVar(&verifyPasswordFile, "password-file", "", "Read the password from the first line of this file")
+	verifyCmd.Flags().StringVar(&verifyKeyfile, "keyfile", "", "Key file given to seal --keyfile, for files that need one")
+	verifyCmd.Flags().BoolVar(&verifyManifest, "manifest", false, "Compare the sealed files with the signed "+checksumManifestFile+" instead of decrypting them")
+	RootCmd.AddCommand(verifyCmd)
+}
@@ -0,0 +1,28 @@
+package cli
+
+import (
+	"aegis/internal/crypto"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var versionCmd = &cobra.Command{
+	Use:   "version",
+	Short: "Print version information",
+	Long:  `Print the aegis version, git commit, build date and the newest sealed file format this build writes. Include this in bug reports.`,
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		printVersion()
+	},
+}
+
+// printVersion prints the build information injected through -ldflags.
+func printVersion() {
+	fmt.Printf("aegis %s (commit %s, built %s)\n", Version, Commit, BuildDate)
+	fmt.Printf("sealed file format: v%d\n", crypto.CurrentFormat)
+}
+
+func init() {
+	RootCmd.AddCommand(versionCmd)
+}
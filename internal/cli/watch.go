@@ -1,14 +1,25 @@
 package cli
 
 import (
+	"aegis/internal/atomicfile"
+	"aegis/internal/crypto"
+	"aegis/internal/xxhash"
+	"aegis/pkg/aegis"
 	"bytes"
 	"crypto/sha256"
+	"encoding/json"
 	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
+	"syscall"
 	"time"
 
 	"github.com/fsnotify/fsnotify"
@@ -17,28 +28,250 @@ import (
 
 // fileSnapshot stores the content and metadata of a file
 type fileSnapshot struct {
-	content []byte
+	content []byte // Nil for files over the tracker's size cap
 	hash    [32]byte
 	lines   []string
+	size    int64
 	modTime time.Time
 }
 
+// contentKept reports whether the snapshot holds the file's content and lines, not just its hash
+func (s *fileSnapshot) contentKept() bool {
+	return s.lines != nil
+}
+
 // fileTracker keeps track of file states for change detection
 type fileTracker struct {
 	snapshots map[string]*fileSnapshot
+	maxSize   int64  // Files larger than this keep only their hash; 0 means no cap
+	hashAlgo  string // The --hash-algo name, recorded in --snapshot-file
+	newHash   func() hash.Hash
 	mu        sync.RWMutex
 }
 
-func newFileTracker() *fileTracker {
+func newFileTracker(maxSize int64, hashAlgo string) *fileTracker {
 	return &fileTracker{
 		snapshots: make(map[string]*fileSnapshot),
+		maxSize:   maxSize,
+		hashAlgo:  hashAlgo,
+		newHash:   watchHashAlgos[hashAlgo],
+	}
+}
+
+// watchHashAlgos are the --hash-algo choices for change detection. Only sha256 resists
+// deliberate collisions; the others are much faster on large files and still tell edits apart
+var watchHashAlgos = map[string]func() hash.Hash{
+	"sha256": sha256.New,
+	"xxhash": func() hash.Hash { return xxhash.New() },
+	"crc32":  func() hash.Hash { return crc32.New(crc32.MakeTable(crc32.Castagnoli)) },
+}
+
+// sum returns the digest of content with the tracker's algorithm, zero-padded to 32 bytes
+func (ft *fileTracker) sum(content []byte) [32]byte {
+	var sum [32]byte
+	h := ft.newHash()
+	h.Write(content)
+	copy(sum[:], h.Sum(nil))
+	return sum
+}
+
+// overCap reports whether a file of the given size is too large to keep in memory
+func (ft *fileTracker) overCap(size int64) bool {
+	return ft.maxSize > 0 && size > ft.maxSize
+}
+
+// watchStats counts the events logged during a watch session
+type watchStats struct {
+	Created  int `json:"created"`
+	Modified int `json:"modified"`
+	Removed  int `json:"removed"`
+	Renamed  int `json:"renamed"`
+	Sealed   int `json:"sealed"`
+	Moved    int `json:"moved"`
+}
+
+// sub returns the events counted in s but not yet in earlier, a snapshot of the same session
+func (s watchStats) sub(earlier watchStats) watchStats {
+	return watchStats{
+		Created:  s.Created - earlier.Created,
+		Modified: s.Modified - earlier.Modified,
+		Removed:  s.Removed - earlier.Removed,
+		Renamed:  s.Renamed - earlier.Renamed,
+		Sealed:   s.Sealed - earlier.Sealed,
+		Moved:    s.Moved - earlier.Moved,
 	}
 }
 
+// tally renders the counts on one line, e.g. "3 created, 10 modified, 1 removed, 0 renamed, 0 moved"
+func (s watchStats) tally() string {
+	line := fmt.Sprintf("%d created, %d modified, %d removed, %d renamed, %d moved", s.Created, s.Modified, s.Removed, s.Renamed, s.Moved)
+	if watchSealOnChange {
+		line += fmt.Sprintf(", %d sealed", s.Sealed)
+	}
+	return line
+}
+
+// moveWindow is how long a removed or renamed file waits for a Create with the same content
+// before it is logged as removed; a match within the window is logged as a single move
+const moveWindow = time.Second
+
+// departure is a removed or renamed file held back while waiting for its possible move target
+type departure struct {
+	path    string
+	relPath string
+	op      fsnotify.Op
+	at      time.Time
+	hash    [32]byte
+}
+
+// sealQuietPeriod is how long a file must go without events before --seal-on-change seals it,
+// so files are not sealed halfway through being written
+const sealQuietPeriod = 500 * time.Millisecond
+
 type changeSummary struct {
 	newSize    int
 	lineSpec   string
 	hasChanges bool
+	added      int
+	modified   int
+	removed    int
+	truncated  bool // Truncated or rotated in place (--follow); logged as such instead of a diff
+}
+
+// watchSealOnChange, when set via --seal-on-change, seals every created or modified file in place
+var watchSealOnChange bool
+
+// watchOnChange holds --on-change: a shell command run for each created, modified, removed,
+// renamed or moved file once it has gone quiet
+var watchOnChange string
+
+// watchPasswordFile holds the --password-file path used by --seal-on-change
+var watchPasswordFile string
+
+// watchPoll, when set via --poll, rescans the directory instead of relying on fsnotify events
+var watchPoll bool
+
+// watchPollInterval is the time between rescans in --poll mode
+var watchPollInterval time.Duration
+
+// watchMaxLogSize holds the --max-log-size threshold; empty disables log rotation
+var watchMaxLogSize string
+
+// watchMaxSnapshotSize holds the --max-snapshot-size threshold; empty keeps every file's content
+var watchMaxSnapshotSize string
+
+// watchPreview holds --preview-lines and --preview-width for the detailed log
+var watchPreview previewConfig
+
+// previewConfig controls how much of a file's content the detailed log shows
+type previewConfig struct {
+	lines int // Lines shown for a new file
+	width int // Columns after which a shown line is truncated
+}
+
+// watchExcludes holds the repeatable --exclude glob patterns for paths that are not watched
+var watchExcludes []string
+
+// watchExcludeDirs holds the repeatable --exclude-dir names, skipped at any depth like the built-in
+// directory excludes; seal is not affected
+var watchExcludeDirs []string
+
+// watchIncludes holds the repeatable --include glob patterns; when set, only matching files are watched
+var watchIncludes []string
+
+// watchDiffStyle selects how the detailed log renders changes: "decorated" (default) or "unified"
+var watchDiffStyle string
+
+// watchSnapshotFile holds --snapshot-file: where file hashes are saved on exit and compared on the next start
+var watchSnapshotFile string
+
+// watchSummaryOnly, when set via --summary-only, replaces the per-event terminal output with periodic tallies
+var watchSummaryOnly bool
+
+// watchSummaryInterval is the time between tallies in --summary-only mode
+var watchSummaryInterval time.Duration
+
+// watchConsole receives the per-event terminal output; io.Discard with --summary-only. Both logs
+// are written in full either way
+var watchConsole io.Writer = os.Stdout
+
+// watchTextExts and watchBinaryExts hold --text-ext and --binary-ext: extensions whose files are
+// always diffed as text, or always logged as binary, whatever their content looks like
+var watchTextExts, watchBinaryExts []string
+
+// watchExtKinds maps each lowercased extension from --text-ext (true) and --binary-ext (false)
+// to whether its files are text
+var watchExtKinds map[string]bool
+
+// watchSince holds --since: files modified this long before startup are reported before live watching begins
+var watchSince time.Duration
+
+// watchFollow, when set via --follow, reports a text file that shrank to something other than the
+// start of its old content as truncated or rotated, instead of diffing it against the old content
+var watchFollow bool
+
+// watchByteOffsets, when set via --byte-offsets, also reports where a modified file's content
+// first differs, as a byte offset, for files where line numbers say little
+var watchByteOffsets bool
+
+// watchCountOnly, when set via --count-only, reports the startup scan as totals on the terminal
+// instead of listing each file found by --since or --snapshot-file; the logs keep the listing
+var watchCountOnly bool
+
+// watchTail, when set via --tail, replaces the per-event terminal output with the new content
+// of every modified or added line, one "path:line: text" line each
+var watchTail bool
+
+// watchBanner receives the session header, notices and footer on the terminal; stderr with
+// --tail, so stdout carries only the changed lines
+var watchBanner io.Writer = os.Stdout
+
+// watchRecursive holds --recursive; when false, subdirectories are not watched
+var watchRecursive bool
+
+// watchMaxDepth holds --max-depth: how many directory levels below the root are watched; 0 means no limit
+var watchMaxDepth int
+
+// watchHashAlgo holds --hash-algo: the hash file snapshots are compared by
+var watchHashAlgo string
+
+// watchLogDir holds --log-dir: the directory each session's timestamped log folder is created in
+var watchLogDir string
+
+// watchSessionLayout is the time layout of each session's log folder name under --log-dir
+const watchSessionLayout = "2006-01-02_15-04-05"
+
+// watchDetailedLogName returns the name of the detailed log in the session folder named timestamp
+func watchDetailedLogName(timestamp string) string {
+	return fmt.Sprintf("watch_detailed_%s.log", timestamp)
+}
+
+// watchFormat selects the basic log format: "text" (default) or "json"
+var watchFormat string
+
+// watchEvent is one file event in the basic log when --format json is used
+type watchEvent struct {
+	Action        string `json:"action"`
+	Path          string `json:"path"`
+	Timestamp     string `json:"timestamp"`
+	Size          int    `json:"size"`
+	Lines         string `json:"lines"`
+	LinesAdded    int    `json:"lines_added"`
+	LinesModified int    `json:"lines_modified"`
+	LinesRemoved  int    `json:"lines_removed"`
+	From          string `json:"from,omitempty"` // Previous path of a moved file
+	// SinceLastSession marks changes found at startup against --snapshot-file rather than seen live
+	SinceLastSession bool `json:"since_last_session,omitempty"`
+}
+
+// watchSessionEvent marks the start and end of a session, or a watcher error, in the JSON basic log
+type watchSessionEvent struct {
+	Action    string      `json:"action"`
+	Timestamp string      `json:"timestamp"`
+	Directory string      `json:"directory,omitempty"`
+	Message   string      `json:"message,omitempty"`
+	Duration  string      `json:"duration,omitempty"`
+	Totals    *watchStats `json:"totals,omitempty"`
 }
 
 var watchCmd = &cobra.Command{
@@ -49,37 +282,171 @@ var watchCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 		dir := args[0]
 
+		if watchFormat != "text" && watchFormat != "json" {
+			errorf("Error: unsupported --format %q (use text or json).\n", watchFormat)
+			return
+		}
+		jsonLog := watchFormat == "json"
+		var maxLogSize int64
+		if watchMaxLogSize != "" {
+			size, err := parseByteSize(watchMaxLogSize)
+			if err != nil {
+				errorf("Error: --max-log-size: %v\n", err)
+				return
+			}
+			maxLogSize = size
+		}
+		var maxSnapshotSize int64
+		if watchMaxSnapshotSize != "" {
+			size, err := parseByteSize(watchMaxSnapshotSize)
+			if err != nil {
+				errorf("Error: --max-snapshot-size: %v\n", err)
+				return
+			}
+			maxSnapshotSize = size
+		}
+		if watchDiffStyle != "decorated" && watchDiffStyle != "unified" {
+			errorf("Error: unsupported --diff-style %q (use decorated or unified).\n", watchDiffStyle)
+			return
+		}
+		if watchSummaryOnly && watchSummaryInterval <= 0 {
+			errorf("Error: --summary-interval must be positive.\n")
+			return
+		}
+		if cmd.Flags().Changed("summary-interval") && !watchSummaryOnly {
+			errorf("Error: --summary-interval only applies with --summary-only.\n")
+			return
+		}
+		if watchTail && watchSummaryOnly {
+			errorf("Error: --tail and --summary-only cannot be used together.\n")
+			return
+		}
+		if watchMaxDepth < 0 {
+			errorf("Error: --max-depth cannot be negative.\n")
+			return
+		}
+		if watchPreview.lines < 0 || watchPreview.width < 10 {
+			errorf("Error: --preview-lines must be at least 0 and --preview-width at least 10.\n")
+			return
+		}
+
+		if watchTail {
+			watchBanner = os.Stderr
+		}
+
 		// Verify directory exists
 		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
-			fmt.Fprintf(os.Stderr, "Error: '%s' is not a valid directory.\n", dir)
+			errorf("Error: '%s' is not a valid directory.\n", dir)
+			return
+		}
+
+		// Build the exclude filter from --exclude and the directory's .aegisignore
+		excludes, err := newExcludeMatcher(watchExcludes)
+		if err != nil {
+			errorf("Error: %v\n", err)
+			return
+		}
+		if _, err := excludes.loadIgnoreFile(dir); err != nil {
+			errorf("Error: %v\n", err)
+			return
+		}
+		for _, pattern := range watchIncludes {
+			if strings.HasSuffix(pattern, "/") {
+				errorf("Error: --include %q: include patterns match files, not directories (use e.g. 'src/*').\n", pattern)
+				return
+			}
+		}
+		includes, err := newExcludeMatcher(watchIncludes)
+		if err != nil {
+			errorf("Error: %v\n", err)
+			return
+		}
+		excludeDirs, err := parseExcludeDirs(watchExcludeDirs)
+		if err != nil {
+			errorf("Error: %v\n", err)
+			return
+		}
+		if cmd.Flags().Changed("since") && watchSince <= 0 {
+			errorf("Error: --since must be positive.\n")
 			return
 		}
+		if watchExtKinds, err = parseExtKinds(watchTextExts, watchBinaryExts); err != nil {
+			errorf("Error: %v\n", err)
+			return
+		}
+		if watchHashAlgos[watchHashAlgo] == nil {
+			errorf("Error: unsupported --hash-algo %q (use sha256, xxhash or crc32).\n", watchHashAlgo)
+			return
+		}
+		if watchLogDir == "" {
+			errorf("Error: --log-dir must not be empty.\n")
+			return
+		}
+		filter := &watchFilter{root: dir, excludes: excludes, includes: includes, excludeDirs: excludeDirs, recursive: watchRecursive, maxDepth: watchMaxDepth}
+
+		// Load the previous session's snapshot before anything is written, so a path that is not
+		// a snapshot file is refused instead of being overwritten on exit
+		var prevState *watchState
+		if watchSnapshotFile != "" {
+			if prevState, err = readWatchState(watchSnapshotFile); err != nil {
+				errorf("Error: --snapshot-file: %v\n", err)
+				return
+			}
+			if prevState != nil && prevState.hashAlgo() != watchHashAlgo { // Every file would look modified.
+				errorf("Error: --snapshot-file %s was saved with --hash-algo %s; use it again, or start a new snapshot file.\n", watchSnapshotFile, prevState.hashAlgo())
+				return
+			}
+		}
+
+		// Seal-on-change: read the password and derive the session key once, up front
+		var sessionKey *crypto.Key
+		var existingKeys *crypto.KeyCache // Opens existing sealed files before they are replaced
+		if watchSealOnChange {
+			password, err := readPassword(watchPasswordFile, true)
+			if err != nil {
+				errorf("Error reading password: %v\n", err)
+				return
+			}
+			existingKeys = crypto.NewKeyCache(password)
+			if sessionKey, err = crypto.NewKey(password, crypto.DefaultKDFParams); err != nil {
+				errorf("Error: %v\n", err)
+				return
+			}
+		}
 
 		// Create log directory structure
-		timestamp := time.Now().Format("2006-01-02_15-04-05")
-		logsDir := "logs"
-		timestampDir := filepath.Join(logsDir, timestamp)
+		started := time.Now()
+		timestamp := started.Format(watchSessionLayout)
+		timestampDir := filepath.Join(watchLogDir, timestamp)
 
 		// Create logs folder if it doesn't exist
 		if err := os.MkdirAll(timestampDir, 0755); err != nil {
-			fmt.Fprintf(os.Stderr, "Failed to create logs directory: %v\n", err)
+			errorf("Failed to create logs directory: %v\n", err)
+			return
+		}
+
+		// The log directory is identified by file identity rather than by name, so it is excluded
+		// however the two paths are spelled: relative or absolute, or through a symlink
+		if err := filter.setLogDir(watchLogDir); err != nil {
+			errorf("Error: --log-dir: %v\n", err)
+			os.Remove(timestampDir)
 			return
 		}
 
 		// Create log file paths
-		detailedLogName := filepath.Join(timestampDir, fmt.Sprintf("watch_detailed_%s.log", timestamp))
+		detailedLogName := filepath.Join(timestampDir, watchDetailedLogName(timestamp))
 		basicLogName := filepath.Join(timestampDir, fmt.Sprintf("watch_basic_%s.log", timestamp))
 
-		detailedLog, err := os.OpenFile(detailedLogName, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		detailedLog, err := openRotatingLog(detailedLogName, maxLogSize)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Failed to create detailed log file: %v\n", err)
+			errorf("Failed to create detailed log file: %v\n", err)
 			return
 		}
 		defer detailedLog.Close()
 
-		basicLog, err := os.OpenFile(basicLogName, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		basicLog, err := openRotatingLog(basicLogName, maxLogSize)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Failed to create basic log file: %v\n", err)
+			errorf("Failed to create basic log file: %v\n", err)
 			return
 		}
 		defer basicLog.Close()
@@ -88,170 +455,717 @@ var watchCmd = &cobra.Command{
 		detailedHeader := fmt.Sprintf("╔═══════════════════════════════════════════════════════════════════════╗\n")
 		detailedHeader += fmt.Sprintf("║                    AEGIS DIRECTORY WATCH SESSION                      ║\n")
 		detailedHeader += fmt.Sprintf("╚═══════════════════════════════════════════════════════════════════════╝\n")
-		detailedHeader += fmt.Sprintf("📁 Directory: %s\n", dir)
-		detailedHeader += fmt.Sprintf("🕐 Started: %s\n", time.Now().Format("2006-01-02 15:04:05"))
-		detailedHeader += fmt.Sprintf("📝 Detailed Log: %s\n", detailedLogName)
-		detailedHeader += fmt.Sprintf("📋 Basic Log: %s\n", basicLogName)
+		detailedHeader += fmt.Sprintf("%s Directory: %s\n", markDir, dir)
+		detailedHeader += fmt.Sprintf("%s Started: %s\n", markTime, started.Format("2006-01-02 15:04:05"))
+		detailedHeader += fmt.Sprintf("%s Detailed Log: %s\n", markDetailedLog, detailedLogName)
+		detailedHeader += fmt.Sprintf("%s Basic Log: %s\n", markBasicLog, basicLogName)
 		detailedHeader += fmt.Sprintf("═══════════════════════════════════════════════════════════════════════\n\n")
-		fmt.Print(detailedHeader)
-		detailedLog.WriteString(detailedHeader)
+		fmt.Fprint(watchBanner, detailedHeader)
+		detailedLog.writeHeader(detailedHeader)
 
 		// Write basic header
-		basicHeader := fmt.Sprintf("AEGIS WATCH LOG - %s\n", time.Now().Format("2006-01-02 15:04:05"))
-		basicHeader += fmt.Sprintf("Directory: %s\n", dir)
-		basicHeader += fmt.Sprintf("Format: [Action] File | Timestamp\n")
-		basicHeader += fmt.Sprintf("═══════════════════════════════════════════════════════════════════════\n\n")
-		basicLog.WriteString(basicHeader)
+		if jsonLog {
+			startLine, _ := json.Marshal(watchSessionEvent{Action: "session_start", Timestamp: started.Format(time.RFC3339), Directory: dir})
+			basicLog.writeHeader(string(startLine) + "\n")
+		} else {
+			basicHeader := fmt.Sprintf("AEGIS WATCH LOG - %s\n", started.Format("2006-01-02 15:04:05"))
+			basicHeader += fmt.Sprintf("Directory: %s\n", dir)
+			basicHeader += fmt.Sprintf("Format: [Action] File | Timestamp\n")
+			basicHeader += fmt.Sprintf("═══════════════════════════════════════════════════════════════════════\n\n")
+			basicLog.writeHeader(basicHeader)
+		}
+
+		// In JSON mode the basic log only receives structured events, not free-form notes
+		var basicNotes io.StringWriter = basicLog
+		if jsonLog {
+			basicNotes = io.Discard.(io.StringWriter)
+		}
 
 		// Initialize file tracker
-		tracker := newFileTracker()
+		tracker := newFileTracker(maxSnapshotSize, watchHashAlgo)
 
 		// Create initial snapshots of all files
-		initMsg := "📸 Taking initial snapshots of all files...\n"
-		fmt.Print(initMsg)
+		initMsg := fmt.Sprintf("%s Taking initial snapshots of all files...\n", markSnap)
+		fmt.Fprint(watchBanner, initMsg)
 		detailedLog.WriteString(initMsg)
-		if err := createInitialSnapshots(tracker, dir); err != nil {
-			msg := fmt.Sprintf("⚠️  Warning: Could not create initial snapshots: %v\n", err)
-			fmt.Fprint(os.Stderr, msg)
+		sealedFiles, err := createInitialSnapshots(tracker, dir, filter)
+		if err != nil {
+			msg := fmt.Sprintf("%s Warning: Could not create initial snapshots: %v\n", markWarn, err)
+			warnf("%s", msg)
 			detailedLog.WriteString(msg)
 		}
-
-		// Create file watcher
-		watcher, err := fsnotify.NewWatcher()
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "❌ Failed to create watcher: %v\n", err)
-			return
+		if watchCountOnly {
+			files, size := tracker.totals()
+			msg := fmt.Sprintf("%s Snapshot: %d files, %d bytes; %d sealed files not watched\n", markStats, files, size, sealedFiles)
+			fmt.Fprint(watchBanner, msg)
+			detailedLog.WriteString(msg)
+		}
+		if watchSince > 0 {
+			changes := recentChanges(tracker.state(dir, watchSnapshotFile), started.Add(-watchSince))
+			logRecentChanges(changes, watchSince, detailedLog, basicLog, jsonLog)
+		}
+		if prevState != nil {
+			changes := diffWatchStates(prevState, tracker.state(dir, watchSnapshotFile))
+			logOfflineChanges(changes, prevState.Saved, started, detailedLog, basicLog, jsonLog)
+		} else if watchSnapshotFile != "" {
+			msg := fmt.Sprintf("%s No previous snapshot in %s; one will be saved when watch stops\n", markSnap, watchSnapshotFile)
+			fmt.Fprint(watchBanner, msg)
+			detailedLog.WriteString(msg)
 		}
-		defer watcher.Close()
 
-		// Add directory and all subdirectories to watcher
-		if err := addDirRecursive(watcher, dir); err != nil {
-			fmt.Fprintf(os.Stderr, "❌ Failed to add directory to watcher: %v\n", err)
-			return
+		// Event sources: fsnotify by default, or periodic rescans with --poll
+		var watcher *fsnotify.Watcher
+		var events <-chan fsnotify.Event
+		var watchErrors <-chan error
+		var pollTick <-chan time.Time
+		var poller *pollState
+		if watchPoll {
+			if watchPollInterval <= 0 {
+				errorf("Error: --poll-interval must be positive.\n")
+				return
+			}
+			poller = newPollState(dir, filter)
+			ticker := time.NewTicker(watchPollInterval)
+			defer ticker.Stop()
+			pollTick = ticker.C
+		} else {
+			// Create file watcher
+			watcher, err = fsnotify.NewWatcher()
+			if err != nil {
+				errorf("%s Failed to create watcher: %v\n", markFail, err)
+				return
+			}
+			defer watcher.Close()
+
+			// Add directory and all subdirectories to watcher
+			if err := addDirRecursive(watcher, dir, filter); err != nil {
+				errorf("%s Failed to add directory to watcher: %v\n", markFail, err)
+				return
+			}
+			events, watchErrors = watcher.Events, watcher.Errors
 		}
 
-		watchMsg := fmt.Sprintf("👀 Watching for changes... (Press Ctrl+C to stop)\n")
+		watchMsg := fmt.Sprintf("%s Watching for changes... (Press Ctrl+C to stop)\n", markWatch)
+		if watchPoll {
+			watchMsg = fmt.Sprintf("%s Polling for changes every %s... (Press Ctrl+C to stop)\n", markWatch, watchPollInterval)
+		}
+		if watchSummaryOnly {
+			watchMsg += fmt.Sprintf("%s Summary only: event counts every %s, full detail in the logs\n", markStats, watchSummaryInterval)
+		}
 		watchMsg += fmt.Sprintf("═══════════════════════════════════════════════════════════════════════\n\n")
-		fmt.Print(watchMsg)
+		fmt.Fprint(watchBanner, watchMsg)
 		detailedLog.WriteString(watchMsg)
 
-		// Watch for events
-		for {
-			select {
-			case event, ok := <-watcher.Events:
-				if !ok {
-					return
+		// Stop cleanly on Ctrl+C or SIGTERM so the logs get their footer
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+		defer signal.Stop(signals)
+
+		var stats watchStats
+
+		// Summary-only mode: per-event output is dropped and the counts are printed on a timer
+		var summaryTick <-chan time.Time
+		var lastTally watchStats // Totals at the previous tally
+		lastTallyAt := time.Now()
+		if watchTail {
+			watchConsole = io.Discard
+		}
+		if watchSummaryOnly {
+			watchConsole = io.Discard
+			ticker := time.NewTicker(watchSummaryInterval)
+			defer ticker.Stop()
+			summaryTick = ticker.C
+		}
+
+		// Seal-on-change bookkeeping: files waiting for their quiet period, and originals we removed
+		// ourselves (their Remove events must not be logged or acted on)
+		pending := make(map[string]time.Time)
+		selfRemoved := make(map[string]bool)
+		var sealTick <-chan time.Time
+		if watchSealOnChange {
+			ticker := time.NewTicker(sealQuietPeriod / 2)
+			defer ticker.Stop()
+			sealTick = ticker.C
+		}
+
+		// sealPending seals the files that have been quiet long enough, or all of them when flushing
+		sealPending := func(flush bool) {
+			for path, last := range pending {
+				if !flush && time.Since(last) < sealQuietPeriod {
+					continue
 				}
+				delete(pending, path)
 
-				// Filter out events for .aegis files and log files
-				if strings.HasSuffix(event.Name, ".aegis") ||
-					strings.HasPrefix(filepath.Base(event.Name), "watch_log_") ||
-					strings.HasPrefix(filepath.Base(event.Name), "watch_detailed_") ||
-					strings.HasPrefix(filepath.Base(event.Name), "watch_basic_") {
+				info, err := os.Lstat(path)
+				if err != nil || !info.Mode().IsRegular() {
+					continue // Gone already, or a symlink or other special file
+				}
+				now := time.Now()
+				relPath, _ := filepath.Rel(dir, path)
+				out := aegis.SealedPath(path)
+				if err := aegis.CheckSealTarget(path, out, existingKeys); err != nil {
+					msg := fmt.Sprintf("%s Not sealing %s: %v\n", markFail, relPath, err)
+					errorf("%s", msg)
+					detailedLog.WriteString(msg)
 					continue
 				}
+				if err := aegis.SealWithKey(path, out, sessionKey, false); err != nil {
+					msg := fmt.Sprintf("%s Failed to seal %s: %v\n", markFail, relPath, err)
+					errorf("%s", msg)
+					detailedLog.WriteString(msg)
+					continue
+				}
+				selfRemoved[path] = true
+				if err := os.Remove(path); err != nil {
+					delete(selfRemoved, path)
+					msg := fmt.Sprintf("Warning: Failed to remove original file %s: %v\n", relPath, err)
+					warnf("%s", msg)
+					detailedLog.WriteString(msg)
+				}
+				tracker.removeSnapshot(path)
+				stats.Sealed++
 
-				// Skip directories
-				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
-					if event.Has(fsnotify.Create) {
-						addDirRecursive(watcher, event.Name)
+				msg := fmt.Sprintf("%s Sealed '%s' -> '%s' (%s)\n\n", markSeal, relPath, filepath.Base(out), now.Format("2006-01-02 15:04:05"))
+				fmt.Fprint(watchConsole, paint(colorGreen, msg))
+				detailedLog.WriteString(msg)
+				if jsonLog {
+					writeJSONLine(basicLog, newWatchEvent("sealed", relPath, now, changeSummary{newSize: int(info.Size()), lineSpec: "-"}))
+				} else {
+					basicLog.WriteString(fmt.Sprintf("[Sealed] %s | %s | -> %s\n", relPath, now.Format("2006-01-02 15:04:05"), filepath.Base(out)))
+				}
+			}
+		}
+
+		// On-change hook: changes wait out hookQuietPeriod, then the command runs once per file
+		hooks := make(hookQueue)
+		queueHook := func(path, relPath, action string, at time.Time) {
+			if watchOnChange != "" {
+				hooks.add(path, relPath, action, at)
+			}
+		}
+		var hookTick <-chan time.Time
+		if watchOnChange != "" {
+			ticker := time.NewTicker(hookQuietPeriod / 2)
+			defer ticker.Stop()
+			hookTick = ticker.C
+		}
+
+		// runHooks runs the command for every change that has gone quiet, one at a time; events
+		// arriving meanwhile are handled once it returns
+		runHooks := func() {
+			for _, ev := range hooks.due(time.Now()) {
+				code, elapsed, err := runHook(watchOnChange, ev, watchBanner)
+				elapsed = elapsed.Round(time.Millisecond)
+				now := time.Now()
+				var msg string
+				if err != nil {
+					msg = fmt.Sprintf("%s On-change command could not run for '%s': %v\n\n", markHook, ev.relPath, err)
+				} else {
+					msg = fmt.Sprintf("%s On-change command for '%s' (%s) exited with status %d after %s\n\n", markHook, ev.relPath, ev.action, code, elapsed)
+				}
+				if err != nil || code != 0 {
+					errorf("%s", msg)
+				} else {
+					fmt.Fprint(watchConsole, msg)
+				}
+				detailedLog.WriteString(msg)
+				if jsonLog {
+					entry := watchHookEvent{Action: "hook", Path: filepath.ToSlash(ev.relPath), Timestamp: now.Format(time.RFC3339), Trigger: ev.action, ExitCode: code, Duration: elapsed.String()}
+					if err != nil {
+						entry.Error = err.Error()
 					}
+					writeJSONLine(basicLog, entry)
+				} else {
+					basicLog.WriteString(fmt.Sprintf("[Hook] %s | %s | %s | exit %d | %s\n", ev.relPath, now.Format("2006-01-02 15:04:05"), ev.action, code, elapsed))
+				}
+			}
+		}
+
+		// Removed and renamed files are held briefly so a matching Create can be logged as a move
+		departures := make(map[string]departure)
+
+		// logDeparture logs a held removal or rename that turned out not to be a move
+		logDeparture := func(d departure) {
+			timestamp := d.at.Format("2006-01-02 15:04:05")
+			if d.op.Has(fsnotify.Remove) {
+				// Detailed log format
+				detailedMsg := fmt.Sprintf("\n┌─── FILE REMOVED ────────────────────────────────────────────\n")
+				detailedMsg += fmt.Sprintf("│ %s Time: %s\n", markRemoved, timestamp)
+				detailedMsg += fmt.Sprintf("│ %s File: %s\n", markFile, d.relPath)
+				detailedMsg += fmt.Sprintf("└─────────────────────────────────────────────────────────────\n\n")
+				fmt.Fprint(watchConsole, paint(colorRed, detailedMsg))
+				detailedLog.WriteString(detailedMsg)
+
+				// Basic log format
+				stats.Removed++
+				if jsonLog {
+					writeJSONLine(basicLog, newWatchEvent("removed", d.relPath, d.at, changeSummary{lineSpec: "-"}))
+				} else {
+					basicLog.WriteString(fmt.Sprintf("[Removed] %s | %s | size 0 bytes | lines -\n", d.relPath, timestamp))
+				}
+				queueHook(d.path, d.relPath, "removed", d.at)
+
+				tracker.removeSnapshot(d.path)
+				return
+			}
+
+			// Detailed log format
+			detailedMsg := fmt.Sprintf("\n┌─── FILE RENAMED ────────────────────────────────────────────\n")
+			detailedMsg += fmt.Sprintf("│ %s Time: %s\n", markRenamed, timestamp)
+			detailedMsg += fmt.Sprintf("│ %s File: %s\n", markFile, d.relPath)
+			detailedMsg += fmt.Sprintf("└─────────────────────────────────────────────────────────────\n\n")
+			fmt.Fprint(watchConsole, paint(colorYellow, detailedMsg))
+			detailedLog.WriteString(detailedMsg)
+
+			// Basic log format
+			stats.Renamed++
+			if jsonLog {
+				writeJSONLine(basicLog, newWatchEvent("renamed", d.relPath, d.at, changeSummary{lineSpec: "-"}))
+			} else {
+				basicLog.WriteString(fmt.Sprintf("[Renamed] %s | %s\n", d.relPath, timestamp))
+			}
+			queueHook(d.path, d.relPath, "renamed", d.at)
+		}
+
+		// flushDepartures logs the departures whose move window has passed, or all of them
+		flushDepartures := func(all bool) {
+			for path, d := range departures {
+				if all || time.Since(d.at) > moveWindow {
+					delete(departures, path)
+					logDeparture(d)
+				}
+			}
+		}
+
+		// matchMove finds the held departure whose last snapshot has the same content as the new
+		// file at path, preferring one with the same base name
+		matchMove := func(path string, now time.Time) (departure, bool) {
+			hash, _, err := tracker.hashFile(path)
+			if err != nil {
+				return departure{}, false
+			}
+			var match departure
+			found := false
+			for _, d := range departures {
+				if d.hash != hash || now.Sub(d.at) > moveWindow {
 					continue
 				}
+				if !found || filepath.Base(d.path) == filepath.Base(path) {
+					match, found = d, true
+				}
+			}
+			return match, found
+		}
 
-				// Get current timestamp
-				timestamp := time.Now().Format("2006-01-02 15:04:05")
-				relPath, _ := filepath.Rel(dir, event.Name)
+		// handleEvent logs one file event, whether delivered by fsnotify or synthesized by polling
+		handleEvent := func(event fsnotify.Event) {
+			// Filter out events for .aegis files, in-progress atomic writes, and log files
+			if isWatchNoise(event.Name) {
+				return
+			}
 
-				// Handle different event types
-				switch {
-				case event.Has(fsnotify.Write):
-					// Detailed log format
-					detailedMsg := fmt.Sprintf("\n┌─── FILE MODIFIED ───────────────────────────────────────────\n")
-					detailedMsg += fmt.Sprintf("│ 📝 Time: %s\n", timestamp)
-					detailedMsg += fmt.Sprintf("│ 📄 File: %s\n", relPath)
-					detailedMsg += fmt.Sprintf("└─────────────────────────────────────────────────────────────\n")
-					fmt.Print(detailedMsg)
-					detailedLog.WriteString(detailedMsg)
+			// The removal of an original we just sealed is not a user change
+			if event.Has(fsnotify.Remove) && selfRemoved[event.Name] {
+				delete(selfRemoved, event.Name)
+				return
+			}
 
-					// Detect changes and gather summary for basic log
-					summary := detectAndShowChanges(tracker, event.Name, detailedLog, basicLog)
-					// Only write to basic log if there were actual content changes
-					if summary.hasChanges {
-						lineSpec := summary.lineSpec
-						if lineSpec == "" {
-							lineSpec = "-"
-						}
-						basicLog.WriteString(fmt.Sprintf("[Modified] %s | %s | size %d bytes | lines %s\n", relPath, timestamp, summary.newSize, lineSpec))
-					}
+			// Skip directories
+			info, statErr := os.Stat(event.Name)
+			isDir := statErr == nil && info.IsDir()
+			if filter.skip(event.Name, isDir) {
+				return
+			}
+			if isDir {
+				if event.Has(fsnotify.Create) && watcher != nil {
+					addDirRecursive(watcher, event.Name, filter)
+				}
+				return
+			}
 
-				case event.Has(fsnotify.Create):
-					// Detailed log format
-					detailedMsg := fmt.Sprintf("\n┌─── FILE CREATED ────────────────────────────────────────────\n")
-					detailedMsg += fmt.Sprintf("│ ➕ Time: %s\n", timestamp)
-					detailedMsg += fmt.Sprintf("│ 📄 File: %s\n", relPath)
-					detailedMsg += fmt.Sprintf("└─────────────────────────────────────────────────────────────\n")
-					fmt.Print(detailedMsg)
-					detailedLog.WriteString(detailedMsg)
+			// Get current timestamp
+			now := time.Now()
+			timestamp := now.Format("2006-01-02 15:04:05")
+			relPath, _ := filepath.Rel(dir, event.Name)
+
+			// Queue created and modified files for sealing once they settle
+			if watchSealOnChange && (event.Has(fsnotify.Write) || event.Has(fsnotify.Create)) {
+				pending[event.Name] = now
+			}
 
-					// Detailed processing and summary
-					summary := showNewFileContent(event.Name, detailedLog, basicLog)
+			// Handle different event types
+			switch {
+			case event.Has(fsnotify.Write):
+				// Detailed log format
+				detailedMsg := fmt.Sprintf("\n┌─── FILE MODIFIED ───────────────────────────────────────────\n")
+				detailedMsg += fmt.Sprintf("│ %s Time: %s\n", markChanged, timestamp)
+				detailedMsg += fmt.Sprintf("│ %s File: %s\n", markFile, relPath)
+				detailedMsg += fmt.Sprintf("└─────────────────────────────────────────────────────────────\n")
+				fmt.Fprint(watchConsole, paint(colorYellow, detailedMsg))
+				detailedLog.WriteString(detailedMsg)
+
+				// Detect changes and gather summary for basic log
+				summary := detectAndShowChanges(tracker, event.Name, relPath, detailedLog, basicNotes, watchPreview)
+				// Only write to basic log if there were actual content changes
+				if summary.hasChanges {
 					lineSpec := summary.lineSpec
 					if lineSpec == "" {
 						lineSpec = "-"
 					}
-					basicLog.WriteString(fmt.Sprintf("[Created] %s | %s | size %d bytes | lines %s\n", relPath, timestamp, summary.newSize, lineSpec))
-					tracker.addSnapshot(event.Name)
+					stats.Modified++
+					action, label := "modified", "Modified"
+					if summary.truncated {
+						action, label = "truncated", "Truncated"
+					}
+					if jsonLog {
+						writeJSONLine(basicLog, newWatchEvent(action, relPath, now, summary))
+					} else {
+						basicLog.WriteString(fmt.Sprintf("[%s] %s | %s | size %d bytes | lines %s\n", label, relPath, timestamp, summary.newSize, lineSpec))
+					}
+					queueHook(event.Name, relPath, action, now)
+				}
+
+			case event.Has(fsnotify.Create):
+				// A Create with the content of a file that just left is a move
+				if from, ok := matchMove(event.Name, now); ok {
+					delete(departures, from.path)
 
-				case event.Has(fsnotify.Remove):
 					// Detailed log format
-					detailedMsg := fmt.Sprintf("\n┌─── FILE REMOVED ────────────────────────────────────────────\n")
-					detailedMsg += fmt.Sprintf("│ ➖ Time: %s\n", timestamp)
-					detailedMsg += fmt.Sprintf("│ 📄 File: %s\n", relPath)
+					detailedMsg := fmt.Sprintf("\n┌─── FILE MOVED ──────────────────────────────────────────────\n")
+					detailedMsg += fmt.Sprintf("│ %s Time: %s\n", markMoved, timestamp)
+					detailedMsg += fmt.Sprintf("│ %s From: %s\n", markFile, from.relPath)
+					detailedMsg += fmt.Sprintf("│ %s To:   %s\n", markFile, relPath)
 					detailedMsg += fmt.Sprintf("└─────────────────────────────────────────────────────────────\n\n")
-					fmt.Print(detailedMsg)
+					fmt.Fprint(watchConsole, paint(colorYellow, detailedMsg))
 					detailedLog.WriteString(detailedMsg)
 
 					// Basic log format
-					basicLog.WriteString(fmt.Sprintf("[Removed] %s | %s | size 0 bytes | lines -\n", relPath, timestamp))
+					stats.Moved++
+					if jsonLog {
+						var movedSize int
+						if statErr == nil {
+							movedSize = int(info.Size())
+						}
+						moved := newWatchEvent("moved", relPath, now, changeSummary{newSize: movedSize, lineSpec: "-"})
+						moved.From = filepath.ToSlash(from.relPath)
+						writeJSONLine(basicLog, moved)
+					} else {
+						basicLog.WriteString(fmt.Sprintf("[Moved] %s -> %s | %s\n", from.relPath, relPath, timestamp))
+					}
+					queueHook(event.Name, relPath, "moved", now)
 
-					tracker.removeSnapshot(event.Name)
+					tracker.removeSnapshot(from.path)
+					tracker.addSnapshot(event.Name)
+					return
+				}
 
-				case event.Has(fsnotify.Rename):
-					// Detailed log format
-					detailedMsg := fmt.Sprintf("\n┌─── FILE RENAMED ────────────────────────────────────────────\n")
-					detailedMsg += fmt.Sprintf("│ 🔄 Time: %s\n", timestamp)
-					detailedMsg += fmt.Sprintf("│ 📄 File: %s\n", relPath)
-					detailedMsg += fmt.Sprintf("└─────────────────────────────────────────────────────────────\n\n")
-					fmt.Print(detailedMsg)
-					detailedLog.WriteString(detailedMsg)
+				// Detailed log format
+				detailedMsg := fmt.Sprintf("\n┌─── FILE CREATED ────────────────────────────────────────────\n")
+				detailedMsg += fmt.Sprintf("│ %s Time: %s\n", markCreated, timestamp)
+				detailedMsg += fmt.Sprintf("│ %s File: %s\n", markFile, relPath)
+				detailedMsg += fmt.Sprintf("└─────────────────────────────────────────────────────────────\n")
+				fmt.Fprint(watchConsole, paint(colorGreen, detailedMsg))
+				detailedLog.WriteString(detailedMsg)
 
-					// Basic log format
-					basicLog.WriteString(fmt.Sprintf("[Renamed] %s | %s\n", relPath, timestamp))
+				// Detailed processing and summary
+				summary := showNewFileContent(event.Name, detailedLog, basicNotes, watchPreview)
+				lineSpec := summary.lineSpec
+				if lineSpec == "" {
+					lineSpec = "-"
+				}
+				stats.Created++
+				if jsonLog {
+					writeJSONLine(basicLog, newWatchEvent("created", relPath, now, summary))
+				} else {
+					basicLog.WriteString(fmt.Sprintf("[Created] %s | %s | size %d bytes | lines %s\n", relPath, timestamp, summary.newSize, lineSpec))
 				}
+				queueHook(event.Name, relPath, "created", now)
+				tracker.addSnapshot(event.Name)
+
+			case event.Has(fsnotify.Remove) || event.Has(fsnotify.Rename):
+				d := departure{path: event.Name, relPath: relPath, op: event.Op, at: now}
+				snapshot, ok := tracker.getSnapshot(event.Name)
+				if !ok { // Nothing to match a move against
+					logDeparture(d)
+					return
+				}
+				d.hash = snapshot.hash
+				departures[event.Name] = d
+			}
+		}
+
+		moveTick := time.NewTicker(moveWindow / 2)
+		defer moveTick.Stop()
+
+		// Watch for events
+	watchLoop:
+		for {
+			select {
+			case sig := <-signals:
+				fmt.Fprintf(watchBanner, "\n%s Received %v, stopping watch...\n", markStop, sig)
+				break watchLoop
+
+			case <-sealTick:
+				sealPending(false)
+
+			case <-hookTick:
+				runHooks()
 
-			case err, ok := <-watcher.Errors:
+			case <-moveTick.C:
+				flushDepartures(false)
+
+			case now := <-summaryTick:
+				fmt.Printf("%s [%s] Last %s: %s (session: %s)\n", markStats, now.Format("15:04:05"), now.Sub(lastTallyAt).Round(time.Second), stats.sub(lastTally).tally(), stats.tally())
+				lastTally, lastTallyAt = stats, now
+
+			case event, ok := <-events:
 				if !ok {
-					return
+					break watchLoop
 				}
-				msg := fmt.Sprintf("⚠️  Watcher error: %v\n", err)
-				fmt.Fprint(os.Stderr, msg)
+				handleEvent(event)
+
+			case <-pollTick:
+				for _, event := range poller.scan(tracker) {
+					handleEvent(event)
+				}
+
+			case err, ok := <-watchErrors:
+				if !ok {
+					break watchLoop
+				}
+				msg := fmt.Sprintf("%s Watcher error: %v\n", markWarn, err)
+				errorf("%s", msg)
 				detailedLog.WriteString(msg)
-				basicLog.WriteString(msg)
+				if jsonLog {
+					writeJSONLine(basicLog, watchSessionEvent{Action: "error", Timestamp: time.Now().Format(time.RFC3339), Message: err.Error()})
+				} else {
+					basicLog.WriteString(msg)
+				}
 			}
 		}
+
+		// Log removals still waiting for a move, and seal anything still waiting so no
+		// plaintext is left behind by the shutdown
+		flushDepartures(true)
+		sealPending(true)
+
+		if watchSnapshotFile != "" {
+			if err := writeWatchState(watchSnapshotFile, tracker.state(dir, watchSnapshotFile)); err != nil {
+				msg := fmt.Sprintf("%s Warning: Could not save snapshot file: %v\n", markWarn, err)
+				warnf("%s", msg)
+				detailedLog.WriteString(msg)
+			}
+		}
+
+		writeWatchFooter(detailedLog, basicLog, stats, started, jsonLog)
 	},
 }
 
+// writeWatchFooter closes the session in both logs with event totals and the session duration
+func writeWatchFooter(detailedLog *rotatingLog, basicLog *rotatingLog, stats watchStats, started time.Time, jsonLog bool) {
+	ended := time.Now()
+	duration := ended.Sub(started).Round(time.Second)
+
+	detailedFooter := fmt.Sprintf("\n═══════════════════════════════════════════════════════════════════════\n")
+	detailedFooter += fmt.Sprintf("╔═══════════════════════════════════════════════════════════════════════╗\n")
+	detailedFooter += fmt.Sprintf("║                     WATCH SESSION SUMMARY                             ║\n")
+	detailedFooter += fmt.Sprintf("╚═══════════════════════════════════════════════════════════════════════╝\n")
+	detailedFooter += fmt.Sprintf("%s Ended: %s\n", markTime, ended.Format("2006-01-02 15:04:05"))
+	detailedFooter += fmt.Sprintf("%s Duration: %s\n", markTimer, duration)
+	detailedFooter += fmt.Sprintf("%s Created: %d\n", markCreated, stats.Created)
+	detailedFooter += fmt.Sprintf("%s Modified: %d\n", markChanged, stats.Modified)
+	detailedFooter += fmt.Sprintf("%s Removed: %d\n", markRemoved, stats.Removed)
+	detailedFooter += fmt.Sprintf("%s Renamed: %d\n", markRenamed, stats.Renamed)
+	detailedFooter += fmt.Sprintf("%s Moved: %d\n", markMoved, stats.Moved)
+	if watchSealOnChange {
+		detailedFooter += fmt.Sprintf("%s Sealed: %d\n", markSeal, stats.Sealed)
+	}
+	detailedFooter += fmt.Sprintf("═══════════════════════════════════════════════════════════════════════\n")
+	fmt.Fprint(watchBanner, detailedFooter)
+	detailedLog.WriteString(detailedFooter)
+
+	if jsonLog {
+		writeJSONLine(basicLog, watchSessionEvent{Action: "session_end", Timestamp: ended.Format(time.RFC3339), Duration: duration.String(), Totals: &stats})
+	} else {
+		basicFooter := fmt.Sprintf("\n═══════════════════════════════════════════════════════════════════════\n")
+		basicFooter += fmt.Sprintf("SESSION END - %s | duration %s\n", ended.Format("2006-01-02 15:04:05"), duration)
+		basicFooter += fmt.Sprintf("Created %d | Modified %d | Removed %d | Renamed %d | Moved %d", stats.Created, stats.Modified, stats.Removed, stats.Renamed, stats.Moved)
+		if watchSealOnChange {
+			basicFooter += fmt.Sprintf(" | Sealed %d", stats.Sealed)
+		}
+		basicFooter += "\n"
+		basicLog.WriteString(basicFooter)
+	}
+
+	// Flush both logs to disk before the deferred Close calls
+	detailedLog.Sync()
+	basicLog.Sync()
+}
+
+// newWatchEvent builds the JSON basic-log entry for a file event
+func newWatchEvent(action, path string, at time.Time, summary changeSummary) watchEvent {
+	return watchEvent{
+		Action:        action,
+		Path:          filepath.ToSlash(path),
+		Timestamp:     at.Format(time.RFC3339),
+		Size:          summary.newSize,
+		Lines:         summary.lineSpec,
+		LinesAdded:    summary.added,
+		LinesModified: summary.modified,
+		LinesRemoved:  summary.removed,
+	}
+}
+
+// writeJSONLine writes v as a single line of JSON
+func writeJSONLine(w io.Writer, v any) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+	w.Write(append(data, '\n'))
+}
+
+// isWatchNoise reports whether events for path come from aegis itself: sealed files and
+// in-progress atomic writes. The logs are never seen, since their directory is not watched
+func isWatchNoise(path string) bool {
+	return strings.HasSuffix(path, ".aegis") || atomicfile.IsTemp(path)
+}
+
+// pollState remembers what the last --poll rescan saw, keyed by path
+type pollState struct {
+	root   string
+	filter *watchFilter
+	seen   map[string]pollEntry
+}
+
+type pollEntry struct {
+	size    int64
+	modTime time.Time
+}
+
+// newPollState records the current tree so the first rescan only reports later changes
+func newPollState(root string, filter *watchFilter) *pollState {
+	p := &pollState{root: root, filter: filter}
+	p.seen = p.walk()
+	return p
+}
+
+// walk lists the regular files the watcher would report on
+func (p *pollState) walk() map[string]pollEntry {
+	found := make(map[string]pollEntry)
+	filepath.Walk(p.root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		if p.filter.skip(path, info.IsDir()) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.IsDir() || !info.Mode().IsRegular() || isWatchNoise(path) {
+			return nil
+		}
+		found[path] = pollEntry{size: info.Size(), modTime: info.ModTime()}
+		return nil
+	})
+	return found
+}
+
+// scan rescans the tree and synthesizes create, write and remove events for what changed
+// since the previous scan. A file whose modTime changed but whose content hash still matches
+// its snapshot only has its snapshot refreshed.
+func (p *pollState) scan(tracker *fileTracker) []fsnotify.Event {
+	current := p.walk()
+	var events []fsnotify.Event
+	for path, entry := range current {
+		old, known := p.seen[path]
+		switch {
+		case !known:
+			events = append(events, fsnotify.Event{Name: path, Op: fsnotify.Create})
+		case entry != old:
+			if snapshot, ok := tracker.getSnapshot(path); ok {
+				if hash, _, err := tracker.hashFile(path); err == nil && hash == snapshot.hash {
+					tracker.addSnapshot(path)
+					continue
+				}
+			}
+			events = append(events, fsnotify.Event{Name: path, Op: fsnotify.Write})
+		}
+	}
+	for path := range p.seen {
+		if _, ok := current[path]; !ok {
+			events = append(events, fsnotify.Event{Name: path, Op: fsnotify.Remove})
+		}
+	}
+	p.seen = current
+	// Removals first, so a file moved between scans can be matched to its Create
+	sort.Slice(events, func(i, j int) bool {
+		ri, rj := events[i].Has(fsnotify.Remove), events[j].Has(fsnotify.Remove)
+		if ri != rj {
+			return ri
+		}
+		return events[i].Name < events[j].Name
+	})
+	return events
+}
+
+// watchFilter decides which paths under the watched root are ignored
+type watchFilter struct {
+	root        string
+	excludes    *excludeMatcher
+	includes    *excludeMatcher // With any patterns, files must match one to be watched
+	excludeDirs []string        // --exclude-dir names, added to shouldExcludeDir's list
+	logDir      os.FileInfo     // The --log-dir directory, never watched so the logs do not report their own writes
+	recursive   bool            // Without it, every subdirectory is skipped and only the root's own files are watched
+	maxDepth    int             // --max-depth: directories this many levels down are skipped; 0 for no limit
+}
+
+// setLogDir records the log directory, which must already exist. It cannot be the watched
+// directory itself, since the root is always watched
+func (f *watchFilter) setLogDir(path string) error {
+	logInfo, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if rootInfo, err := os.Stat(f.root); err == nil && os.SameFile(logInfo, rootInfo) {
+		return fmt.Errorf("'%s' is the watched directory; choose a directory outside it or a subdirectory", path)
+	}
+	f.logDir = logInfo
+	return nil
+}
+
+// skip reports whether path is a subdirectory with --recursive=false or beyond --max-depth, matches the built-in
+// directory excludes or an --exclude-dir name, an --exclude pattern, or a .aegisignore pattern, is the log directory,
+// or is a file that matches no --include pattern. The root itself is never skipped.
+func (f *watchFilter) skip(path string, isDir bool) bool {
+	rel, err := filepath.Rel(f.root, path)
+	if err != nil || rel == "." {
+		return false
+	}
+	if isDir && (!f.recursive || beyondMaxDepth(rel, f.maxDepth) || shouldExcludeDir(filepath.Base(path), f.excludeDirs...)) {
+		return true
+	}
+	if isDir && f.logDir != nil {
+		if info, err := os.Stat(path); err == nil && os.SameFile(info, f.logDir) {
+			return true
+		}
+	}
+	if f.excludes.match(rel, isDir) {
+		return true
+	}
+	return !isDir && len(f.includes.patterns) > 0 && !f.includes.match(rel, false)
+}
+
 // addDirRecursive adds a directory and all its subdirectories to the watcher
-func addDirRecursive(watcher *fsnotify.Watcher, dir string) error {
+func addDirRecursive(watcher *fsnotify.Watcher, dir string, filter *watchFilter) error {
 	return filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
 		if info.IsDir() {
-			// Skip common directories that shouldn't be watched
-			if shouldExcludeDir(info.Name()) && path != dir {
+			// Skip common and user-excluded directories that shouldn't be watched
+			if filter.skip(path, true) {
 				return filepath.SkipDir
 			}
 			if err := watcher.Add(path); err != nil {
@@ -262,10 +1176,11 @@ func addDirRecursive(watcher *fsnotify.Watcher, dir string) error {
 	})
 }
 
-// shouldExcludeDir checks if a directory should be excluded from watching
-func shouldExcludeDir(name string) bool {
+// shouldExcludeDir checks if a directory should be excluded from watching: one of the built-in
+// names, or one of extra (--exclude-dir)
+func shouldExcludeDir(name string, extra ...string) bool {
 	excludeList := []string{".git", "vendor", "node_modules", "target", ".idea", ".vscode"}
-	for _, excluded := range excludeList {
+	for _, excluded := range append(excludeList, extra...) {
 		if name == excluded {
 			return true
 		}
@@ -273,34 +1188,76 @@ func shouldExcludeDir(name string) bool {
 	return false
 }
 
-// addSnapshot adds or updates a file snapshot
+// parseExcludeDirs checks the --exclude-dir values: directory names, with an optional trailing
+// slash, since they are compared with each directory's base name like the built-in list
+func parseExcludeDirs(values []string) ([]string, error) {
+	var names []string
+	for _, v := range values {
+		name := strings.TrimRight(v, `/\`)
+		if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+			return nil, fmt.Errorf("invalid --exclude-dir %q: give a directory name such as dist (use --exclude for paths)", v)
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+// addSnapshot adds or updates a file snapshot. Files over the size cap are hashed as a
+// stream and keep no content, so memory stays bounded
 func (ft *fileTracker) addSnapshot(path string) error {
 	ft.mu.Lock()
 	defer ft.mu.Unlock()
 
-	content, err := os.ReadFile(path)
+	info, err := os.Stat(path)
 	if err != nil {
 		return err
 	}
 
-	info, err := os.Stat(path)
+	if ft.overCap(info.Size()) {
+		hash, size, err := ft.hashFile(path)
+		if err != nil {
+			return err
+		}
+		ft.snapshots[path] = &fileSnapshot{hash: hash, size: size, modTime: info.ModTime()}
+		return nil
+	}
+
+	content, err := os.ReadFile(path)
 	if err != nil {
 		return err
 	}
 
 	lines := strings.Split(string(content), "\n")
-	hash := sha256.Sum256(content)
+	hash := ft.sum(content)
 
 	ft.snapshots[path] = &fileSnapshot{
 		content: content,
 		hash:    hash,
 		lines:   lines,
+		size:    int64(len(content)),
 		modTime: info.ModTime(),
 	}
 
 	return nil
 }
 
+// hashFile returns the digest (as sum does) and size of a file without loading it into memory
+func (ft *fileTracker) hashFile(path string) ([32]byte, int64, error) {
+	var hash [32]byte
+	f, err := os.Open(path)
+	if err != nil {
+		return hash, 0, err
+	}
+	defer f.Close()
+	h := ft.newHash()
+	n, err := io.Copy(h, f)
+	if err != nil {
+		return hash, 0, err
+	}
+	copy(hash[:], h.Sum(nil))
+	return hash, n, nil
+}
+
 // removeSnapshot removes a file snapshot
 func (ft *fileTracker) removeSnapshot(path string) {
 	ft.mu.Lock()
@@ -308,6 +1265,14 @@ func (ft *fileTracker) removeSnapshot(path string) {
 	delete(ft.snapshots, path)
 }
 
+// totals returns the number of files snapshotted and their combined size
+func (ft *fileTracker) totals() (files int, size int64) {
+	for _, s := range ft.snapshots {
+		size += int64(s.size)
+	}
+	return len(ft.snapshots), size
+}
+
 // getSnapshot retrieves a file snapshot
 func (ft *fileTracker) getSnapshot(path string) (*fileSnapshot, bool) {
 	ft.mu.RLock()
@@ -316,38 +1281,52 @@ func (ft *fileTracker) getSnapshot(path string) (*fileSnapshot, bool) {
 	return snapshot, exists
 }
 
-// createInitialSnapshots creates snapshots of all files in directory
-func createInitialSnapshots(tracker *fileTracker, dir string) error {
-	return filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+// createInitialSnapshots creates snapshots of all files in directory and returns how many
+// sealed files it passed over
+func createInitialSnapshots(tracker *fileTracker, dir string, filter *watchFilter) (sealed int, err error) {
+	err = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return nil
 		}
 
-		if info.IsDir() {
-			if shouldExcludeDir(info.Name()) && path != dir {
+		if filter.skip(path, info.IsDir()) {
+			if info.IsDir() {
 				return filepath.SkipDir
 			}
 			return nil
 		}
+		if info.IsDir() {
+			return nil
+		}
 
-		// Skip symlinks and .aegis files
-		if (info.Mode()&os.ModeSymlink) != 0 || strings.HasSuffix(path, ".aegis") {
+		// Skip symlinks and .aegis files; the sealed files are counted for --count-only
+		if (info.Mode() & os.ModeSymlink) != 0 {
+			return nil
+		}
+		if strings.HasSuffix(path, ".aegis") {
+			sealed++
 			return nil
 		}
 
 		tracker.addSnapshot(path)
 		return nil
 	})
+	return sealed, err
 }
 
 // detectAndShowChanges detects and displays line-by-line changes in a file
-func detectAndShowChanges(tracker *fileTracker, path string, detailedLog *os.File, basicLog *os.File) changeSummary {
+func detectAndShowChanges(tracker *fileTracker, path, relPath string, detailedLog *rotatingLog, basicLog io.StringWriter, preview previewConfig) changeSummary {
 	oldSnapshot, exists := tracker.getSnapshot(path)
 
+	// Files over --max-snapshot-size are compared by hash only
+	if info, err := os.Stat(path); err == nil && exists && (!oldSnapshot.contentKept() || tracker.overCap(info.Size())) {
+		return showLargeFileChange(tracker, path, oldSnapshot, detailedLog, basicLog)
+	}
+
 	content, err := os.ReadFile(path)
 	if err != nil {
-		msg := fmt.Sprintf("│ ⚠️  Could not read file: %v\n", err)
-		fmt.Print(msg)
+		msg := fmt.Sprintf("│ %s Could not read file: %v\n", markWarn, err)
+		fmt.Fprint(watchConsole, msg)
 		detailedLog.WriteString(msg)
 		basicLog.WriteString(msg)
 		return changeSummary{newSize: 0, lineSpec: "-", hasChanges: false}
@@ -356,46 +1335,81 @@ func detectAndShowChanges(tracker *fileTracker, path string, detailedLog *os.Fil
 	newLines := strings.Split(string(content), "\n")
 	newSize := len(content)
 
+	if !exists && !isTextFile(path, content) {
+		msg := fmt.Sprintf("│ %s New binary file, %d bytes\n\n", markFile, newSize)
+		fmt.Fprint(watchConsole, msg)
+		detailedLog.WriteString(msg)
+		basicLog.WriteString(msg)
+		tracker.addSnapshot(path)
+		return changeSummary{newSize: newSize, lineSpec: "-", hasChanges: true}
+	}
 	if !exists {
-		msg := fmt.Sprintf("│ 📄 New file with %d lines\n\n", len(newLines))
-		fmt.Print(msg)
+		msg := fmt.Sprintf("│ %s New file with %d lines\n\n", markFile, len(newLines))
+		fmt.Fprint(watchConsole, msg)
 		detailedLog.WriteString(msg)
 		basicLog.WriteString(msg)
 		tracker.addSnapshot(path)
-		return changeSummary{newSize: newSize, lineSpec: formatLineRangeFromCount(len(newLines)), hasChanges: len(newLines) > 0}
+		return changeSummary{newSize: newSize, lineSpec: formatLineRangeFromCount(len(newLines)), hasChanges: len(newLines) > 0, added: len(newLines)}
 	}
 
-	newHash := sha256.Sum256(content)
+	newHash := tracker.sum(content)
 	if bytes.Equal(oldSnapshot.hash[:], newHash[:]) {
-		msg := "│ ℹ️  File metadata changed but content is identical\n\n"
-		fmt.Print(msg)
+		msg := fmt.Sprintf("│ %s File metadata changed but content is identical\n\n", markInfo)
+		fmt.Fprint(watchConsole, msg)
 		detailedLog.WriteString(msg)
 		return changeSummary{newSize: newSize, lineSpec: "-", hasChanges: false}
 	}
 
-	oldLines := oldSnapshot.lines
-	changedLines := []int{}
-	addedLines := []int{}
-	removedLines := []int{}
+	// Binary content has no meaningful lines; splitting it on newlines would log garbage
+	if !isTextFile(path, content) || !isTextFile(path, oldSnapshot.content) {
+		return showBinaryFileChange(tracker, path, oldSnapshot, content, newHash, detailedLog)
+	}
 
-	for i := 0; i < len(oldLines); i++ {
-		if i >= len(newLines) {
-			removedLines = append(removedLines, i+1)
-		} else if oldLines[i] != newLines[i] {
-			changedLines = append(changedLines, i+1)
-		}
+	// A log cut back to nothing, or replaced by shorter new content, would otherwise diff as
+	// every old line removed
+	oldSize := len(oldSnapshot.content)
+	if watchFollow && newSize < oldSize && (newSize == 0 || !bytes.HasPrefix(oldSnapshot.content, content)) {
+		return showTruncatedFile(tracker, path, relPath, oldSize, content, detailedLog, preview)
 	}
 
-	if len(newLines) > len(oldLines) {
-		for i := len(oldLines); i < len(newLines); i++ {
-			addedLines = append(addedLines, i+1)
+	oldLines := oldSnapshot.lines
+	changedLines := []int{} // New line numbers of modified lines
+	changedFrom := []int{}  // Matching old line numbers, parallel to changedLines
+	addedLines := []int{}   // New line numbers of inserted lines
+	removedLines := []int{} // Old line numbers of deleted lines
+
+	// Within each run of edits, deletions paired with insertions are modifications;
+	// the surplus on either side are pure removals or additions
+	var dels, ins []int
+	flush := func() {
+		paired := min(len(dels), len(ins))
+		for i := 0; i < paired; i++ {
+			changedLines = append(changedLines, ins[i]+1)
+			changedFrom = append(changedFrom, dels[i]+1)
+		}
+		for _, idx := range ins[paired:] {
+			addedLines = append(addedLines, idx+1)
+		}
+		for _, idx := range dels[paired:] {
+			removedLines = append(removedLines, idx+1)
+		}
+		dels, ins = dels[:0], ins[:0]
+	}
+	for _, op := range diffLines(oldLines, newLines) {
+		switch op.kind {
+		case diffDelete:
+			dels = append(dels, op.oldIdx)
+		case diffInsert:
+			ins = append(ins, op.newIdx)
+		default:
+			flush()
 		}
 	}
+	flush()
 
-	oldSize := len(oldSnapshot.content)
 	sizeDiff := newSize - oldSize
 
-	summaryMsg := fmt.Sprintf("│ 📊 Summary: ")
+	summaryMsg := fmt.Sprintf("│ %s Summary: ", markStats)
 	if len(changedLines) > 0 {
 		summaryMsg += fmt.Sprintf("%d line(s) modified, ", len(changedLines))
 	}
@@ -414,7 +1428,11 @@ func detectAndShowChanges(tracker *fileTracker, path string, detailedLog *os.Fil
 	}
 	summaryMsg += "\n"
 
-	fmt.Print(summaryMsg)
+	if watchByteOffsets {
+		summaryMsg += byteOffsetLine(oldSnapshot.content, content)
+	}
+
+	fmt.Fprint(watchConsole, summaryMsg)
 	detailedLog.WriteString(summaryMsg)
 
 	lineSet := make(map[int]struct{})
@@ -437,70 +1455,121 @@ func detectAndShowChanges(tracker *fileTracker, path string, detailedLog *os.Fil
 		lineSpec = "-"
 	}
 
-	if len(changedLines) > 0 {
-		detailedMsg := fmt.Sprintf("│\n│ ✏️  Modified Lines: %v\n", changedLines)
-		fmt.Print(detailedMsg)
-		detailedLog.WriteString(detailedMsg)
-
-		for _, lineNum := range changedLines {
-			idx := lineNum - 1
-			if idx < len(oldLines) && idx < len(newLines) {
-				detailedMsg = fmt.Sprintf("│   • Line %d:\n", lineNum)
-				fmt.Print(detailedMsg)
-				detailedLog.WriteString(detailedMsg)
+	if watchDiffStyle == "unified" {
+		// Standard unified diff hunks, ready to paste into review tools
+		hunks := unifiedDiff("a/"+filepath.ToSlash(relPath), "b/"+filepath.ToSlash(relPath), oldLines, newLines, 3)
+		fmt.Fprint(watchConsole, hunks)
+		detailedLog.WriteString(hunks)
+	} else {
+		if len(changedLines) > 0 {
+			detailedMsg := fmt.Sprintf("│\n│ %s Modified Lines: %v\n", markEdited, changedLines)
+			fmt.Fprint(watchConsole, detailedMsg)
+			detailedLog.WriteString(detailedMsg)
 
-				detailedMsg = fmt.Sprintf("│     [-] %s\n", truncate(oldLines[idx], 70))
-				fmt.Print(detailedMsg)
-				detailedLog.WriteString(detailedMsg)
+			for i, lineNum := range changedLines {
+				oldIdx, idx := changedFrom[i]-1, lineNum-1
+				if oldIdx < len(oldLines) && idx < len(newLines) {
+					detailedMsg = fmt.Sprintf("│   • Line %d:\n", lineNum)
+					fmt.Fprint(watchConsole, detailedMsg)
+					detailedLog.WriteString(detailedMsg)
 
-				detailedMsg = fmt.Sprintf("│     [+] %s\n", truncate(newLines[idx], 70))
-				fmt.Print(detailedMsg)
-				detailedLog.WriteString(detailedMsg)
+					detailedMsg = fmt.Sprintf("│     [-] %s\n", truncate(oldLines[oldIdx], preview.width))
+					fmt.Fprint(watchConsole, detailedMsg)
+					detailedLog.WriteString(detailedMsg)
 
-				charChanges := detectCharacterChanges(oldLines[idx], newLines[idx])
-				if charChanges != "" {
-					detailedMsg = fmt.Sprintf("│     🔤  %s\n", charChanges)
-					fmt.Print(detailedMsg)
+					detailedMsg = fmt.Sprintf("│     [+] %s\n", truncate(newLines[idx], preview.width))
+					fmt.Fprint(watchConsole, detailedMsg)
 					detailedLog.WriteString(detailedMsg)
+
+					charChanges := detectCharacterChanges(oldLines[oldIdx], newLines[idx])
+					if charChanges != "" {
+						detailedMsg = fmt.Sprintf("│     %s %s\n", markChars, charChanges)
+						fmt.Fprint(watchConsole, detailedMsg)
+						detailedLog.WriteString(detailedMsg)
+					}
 				}
 			}
 		}
-	}
 
-	if len(addedLines) > 0 {
-		detailedMsg := fmt.Sprintf("│\n│ ➕ Added Lines: %v\n", addedLines)
-		fmt.Print(detailedMsg)
-		detailedLog.WriteString(detailedMsg)
+		if len(addedLines) > 0 {
+			detailedMsg := fmt.Sprintf("│\n│ %s Added Lines: %v\n", markCreated, addedLines)
+			fmt.Fprint(watchConsole, detailedMsg)
+			detailedLog.WriteString(detailedMsg)
 
-		for _, lineNum := range addedLines {
-			idx := lineNum - 1
-			if idx < len(newLines) {
-				detailedMsg = fmt.Sprintf("│   • Line %d: %s\n", lineNum, truncate(newLines[idx], 70))
-				fmt.Print(detailedMsg)
-				detailedLog.WriteString(detailedMsg)
+			for _, lineNum := range addedLines {
+				idx := lineNum - 1
+				if idx < len(newLines) {
+					detailedMsg = fmt.Sprintf("│   • Line %d: %s\n", lineNum, truncate(newLines[idx], preview.width))
+					fmt.Fprint(watchConsole, detailedMsg)
+					detailedLog.WriteString(detailedMsg)
+				}
 			}
 		}
-	}
 
-	if len(removedLines) > 0 {
-		detailedMsg := fmt.Sprintf("│\n│ ➖ Removed Lines: %v\n", removedLines)
-		fmt.Print(detailedMsg)
-		detailedLog.WriteString(detailedMsg)
+		if len(removedLines) > 0 {
+			detailedMsg := fmt.Sprintf("│\n│ %s Removed Lines: %v\n", markRemoved, removedLines)
+			fmt.Fprint(watchConsole, detailedMsg)
+			detailedLog.WriteString(detailedMsg)
+		}
 	}
 
 	closingMsg := "└─────────────────────────────────────────────────────────────\n\n"
-	fmt.Print(closingMsg)
+	fmt.Fprint(watchConsole, closingMsg)
 	detailedLog.WriteString(closingMsg)
 
+	if watchTail {
+		tailLines(relPath, newLines, changedLines, addedLines)
+	}
+
 	tracker.addSnapshot(path)
 
 	return changeSummary{
 		newSize:    newSize,
 		lineSpec:   lineSpec,
 		hasChanges: len(lineIndices) > 0,
+		added:      len(addedLines),
+		modified:   len(changedLines),
+		removed:    len(removedLines),
 	}
 }
 
+// showTruncatedFile reports a --follow file that was truncated or rotated in place and
+// re-snapshots it, so later writes are diffed against the new content. Its lines are all new
+func showTruncatedFile(tracker *fileTracker, path, relPath string, oldSize int, content []byte, detailedLog *rotatingLog, preview previewConfig) changeSummary {
+	var lines []string
+	if len(content) > 0 {
+		lines = strings.Split(string(content), "\n")
+	}
+	msg := fmt.Sprintf("│ %s File truncated/rotated: size %d → %d bytes, now %d line(s)\n", markTruncated, oldSize, len(content), len(lines))
+	for i := 0; i < min(len(lines), preview.lines); i++ {
+		if lines[i] != "" {
+			msg += fmt.Sprintf("│   %d: %s\n", i+1, truncate(lines[i], preview.width))
+		}
+	}
+	if len(lines) > preview.lines && preview.lines > 0 {
+		msg += fmt.Sprintf("│   ... (%d more lines)\n", len(lines)-preview.lines)
+	}
+	msg += "└─────────────────────────────────────────────────────────────\n\n"
+	fmt.Fprint(watchConsole, msg)
+	detailedLog.WriteString(msg)
+
+	if watchTail { // Like tail -F: a notice on stderr, then the file's new lines
+		fmt.Fprintf(watchBanner, "%s %s: file truncated/rotated\n", markTruncated, relPath)
+		n := len(lines)
+		if n > 0 && lines[n-1] == "" { // The text after the final newline
+			n--
+		}
+		all := make([]int, n)
+		for i := range all {
+			all[i] = i + 1
+		}
+		tailLines(relPath, lines, nil, all)
+	}
+
+	tracker.addSnapshot(path)
+	return changeSummary{newSize: len(content), lineSpec: formatLineRangeFromCount(len(lines)), hasChanges: true, added: len(lines), truncated: true}
+}
+
 // detectCharacterChanges detects and describes character-level changes between two strings
 func detectCharacterChanges(oldStr, newStr string) string {
 	if oldStr == newStr {
@@ -575,8 +1644,86 @@ func detectCharacterChanges(oldStr, newStr string) string {
 	return fmt.Sprintf("Changed %d character(s)", diffCount)
 }
 
+// tailLines prints the new content of the changed and added lines of a file to stdout for
+// --tail, in line order and untruncated
+func tailLines(relPath string, newLines []string, changedLines, addedLines []int) {
+	lineNums := append(append([]int{}, changedLines...), addedLines...)
+	sort.Ints(lineNums)
+	for _, lineNum := range lineNums {
+		if lineNum <= len(newLines) {
+			fmt.Printf("%s:%d: %s\n", relPath, lineNum, newLines[lineNum-1])
+		}
+	}
+}
+
+// showLargeFileChange reports a change to a file too large for a line diff: only whether its
+// content changed and by how much its size did
+func showLargeFileChange(tracker *fileTracker, path string, oldSnapshot *fileSnapshot, detailedLog *rotatingLog, basicLog io.StringWriter) changeSummary {
+	hash, size, err := tracker.hashFile(path)
+	if err != nil {
+		msg := fmt.Sprintf("│ %s Could not read file: %v\n", markWarn, err)
+		fmt.Fprint(watchConsole, msg)
+		detailedLog.WriteString(msg)
+		basicLog.WriteString(msg)
+		return changeSummary{newSize: 0, lineSpec: "-", hasChanges: false}
+	}
+	if hash == oldSnapshot.hash {
+		msg := fmt.Sprintf("│ %s File metadata changed but content is identical\n\n", markInfo)
+		fmt.Fprint(watchConsole, msg)
+		detailedLog.WriteString(msg)
+		return changeSummary{newSize: int(size), lineSpec: "-", hasChanges: false}
+	}
+
+	msg := fmt.Sprintf("│ %s Summary: content changed, size %+d bytes (over --max-snapshot-size; no line diff)\n", markStats, size-oldSnapshot.size)
+	msg += "└─────────────────────────────────────────────────────────────\n\n"
+	fmt.Fprint(watchConsole, msg)
+	detailedLog.WriteString(msg)
+	tracker.addSnapshot(path)
+	return changeSummary{newSize: int(size), lineSpec: "-", hasChanges: true}
+}
+
+// showBinaryFileChange reports a change to a binary file by its size and content hash instead
+// of a line diff
+func showBinaryFileChange(tracker *fileTracker, path string, oldSnapshot *fileSnapshot, content []byte, newHash [32]byte, detailedLog *rotatingLog) changeSummary {
+	newSize := len(content)
+	msg := fmt.Sprintf("│ %s Summary: binary file changed, size %d → %d bytes, hash %x → %x\n", markStats, oldSnapshot.size, newSize, oldSnapshot.hash[:4], newHash[:4])
+	if watchByteOffsets {
+		msg += byteOffsetLine(oldSnapshot.content, content)
+	}
+	msg += "└─────────────────────────────────────────────────────────────\n\n"
+	fmt.Fprint(watchConsole, msg)
+	detailedLog.WriteString(msg)
+	tracker.addSnapshot(path)
+	return changeSummary{newSize: newSize, lineSpec: "-", hasChanges: true}
+}
+
+// byteOffsetLine is the --byte-offsets line for a change from old to new: where the content first
+// differs, how the size changed, and the length of the differing span in each version once the
+// common prefix and suffix are trimmed
+func byteOffsetLine(old, new []byte) string {
+	prefix := 0
+	for prefix < len(old) && prefix < len(new) && old[prefix] == new[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(old)-prefix && suffix < len(new)-prefix && old[len(old)-1-suffix] == new[len(new)-1-suffix] {
+		suffix++
+	}
+	oldSpan, newSpan := len(old)-prefix-suffix, len(new)-prefix-suffix
+	return fmt.Sprintf("│ %s First change at byte %s (%+d bytes), %s → %s bytes differ\n", markOffset, groupDigits(prefix), len(new)-len(old), groupDigits(oldSpan), groupDigits(newSpan))
+}
+
+// groupDigits formats n with thousands separators, e.g. 1,204
+func groupDigits(n int) string {
+	s := strconv.Itoa(n)
+	for i := len(s) - 3; i > 0 && s[i-1] != '-'; i -= 3 {
+		s = s[:i] + "," + s[i:]
+	}
+	return s
+}
+
 // showNewFileContent displays information about a newly created file
-func showNewFileContent(path string, detailedLog *os.File, basicLog *os.File) changeSummary {
+func showNewFileContent(path string, detailedLog *rotatingLog, basicLog io.StringWriter, preview previewConfig) changeSummary {
 	// Retry logic for Windows file locking issues
 	var content []byte
 	var err error
@@ -597,8 +1744,8 @@ func showNewFileContent(path string, detailedLog *os.File, basicLog *os.File) ch
 	}
 
 	if err != nil {
-		msg := fmt.Sprintf("│ ⚠️  Could not read file: %v\n└─────────────────────────────────────────────────────────────\n\n", err)
-		fmt.Print(msg)
+		msg := fmt.Sprintf("│ %s Could not read file: %v\n└─────────────────────────────────────────────────────────────\n\n", markWarn, err)
+		fmt.Fprint(watchConsole, msg)
 		detailedLog.WriteString(msg)
 		basicLog.WriteString(msg)
 		return changeSummary{newSize: 0, lineSpec: "-", hasChanges: false}
@@ -607,37 +1754,37 @@ func showNewFileContent(path string, detailedLog *os.File, basicLog *os.File) ch
 	lines := strings.Split(string(content), "\n")
 
 	// Basic info for detailed log and terminal
-	basicMsg := fmt.Sprintf("│ 📊 Size: %d bytes, %d line(s)\n", len(content), len(lines))
-	fmt.Print(basicMsg)
+	basicMsg := fmt.Sprintf("│ %s Size: %d bytes, %d line(s)\n", markStats, len(content), len(lines))
+	fmt.Fprint(watchConsole, basicMsg)
 	detailedLog.WriteString(basicMsg)
 	// Don't write size info to basic log
 
 	// Show first few lines if it's a text file (detailed only)
-	if isTextFile(content) && len(lines) > 0 {
-		detailedMsg := "│\n│ 📝 Content Preview:\n"
-		fmt.Print(detailedMsg)
+	if isTextFile(path, content) && len(lines) > 0 && preview.lines > 0 {
+		detailedMsg := fmt.Sprintf("│\n│ %s Content Preview:\n", markPreview)
+		fmt.Fprint(watchConsole, detailedMsg)
 		detailedLog.WriteString(detailedMsg)
 
-		previewLines := 5
+		previewLines := preview.lines
 		if len(lines) < previewLines {
 			previewLines = len(lines)
 		}
 		for i := 0; i < previewLines; i++ {
 			if lines[i] != "" {
-				detailedMsg = fmt.Sprintf("│   %d: %s\n", i+1, truncate(lines[i], 70))
-				fmt.Print(detailedMsg)
+				detailedMsg = fmt.Sprintf("│   %d: %s\n", i+1, truncate(lines[i], preview.width))
+				fmt.Fprint(watchConsole, detailedMsg)
 				detailedLog.WriteString(detailedMsg)
 			}
 		}
 		if len(lines) > previewLines {
 			detailedMsg = fmt.Sprintf("│   ... (%d more lines)\n", len(lines)-previewLines)
-			fmt.Print(detailedMsg)
+			fmt.Fprint(watchConsole, detailedMsg)
 			detailedLog.WriteString(detailedMsg)
 		}
 	}
 
 	closingMsg := "└─────────────────────────────────────────────────────────────\n\n"
-	fmt.Print(closingMsg)
+	fmt.Fprint(watchConsole, closingMsg)
 	detailedLog.WriteString(closingMsg)
 	// Don't write closing box to basic log
 
@@ -645,6 +1792,7 @@ func showNewFileContent(path string, detailedLog *os.File, basicLog *os.File) ch
 		newSize:    len(content),
 		lineSpec:   formatLineRangeFromCount(len(lines)),
 		hasChanges: len(lines) > 0,
+		added:      len(lines),
 	}
 }
 
@@ -696,6 +1844,35 @@ func formatLineRangeFromCount(count int) string {
 	return fmt.Sprintf("1-%d", count)
 }
 
+// parseExtKinds builds watchExtKinds from the --text-ext and --binary-ext values. The leading
+// dot is optional, case is ignored, and an extension may not be given to both flags
+func parseExtKinds(text, binary []string) (map[string]bool, error) {
+	kinds := make(map[string]bool)
+	add := func(flag string, exts []string, isText bool) error {
+		for _, ext := range exts {
+			norm := strings.ToLower(strings.TrimSpace(ext))
+			if norm != "" && !strings.HasPrefix(norm, ".") {
+				norm = "." + norm
+			}
+			if len(norm) < 2 || strings.ContainsAny(norm[1:], `./\`) {
+				return fmt.Errorf("%s %q: expected a single extension such as .json", flag, ext)
+			}
+			if was, ok := kinds[norm]; ok && was != isText {
+				return fmt.Errorf("%s is given to both --text-ext and --binary-ext", norm)
+			}
+			kinds[norm] = isText
+		}
+		return nil
+	}
+	if err := add("--text-ext", text, true); err != nil {
+		return nil, err
+	}
+	if err := add("--binary-ext", binary, false); err != nil {
+		return nil, err
+	}
+	return kinds, nil
+}
+
 // truncate truncates a string to a maximum length
 func truncate(s string, maxLen int) string {
 	if len(s) <= maxLen {
@@ -704,8 +1881,12 @@ func truncate(s string, maxLen int) string {
 	return s[:maxLen-3] + "..."
 }
 
-// isTextFile checks if content appears to be text
-func isTextFile(content []byte) bool {
+// isTextFile checks if content appears to be text. An extension given to --text-ext or
+// --binary-ext decides without looking at the content
+func isTextFile(path string, content []byte) bool {
+	if text, ok := watchExtKinds[strings.ToLower(filepath.Ext(path))]; ok {
+		return text
+	}
 	if len(content) == 0 {
 		return true
 	}
@@ -734,5 +1915,33 @@ func isTextFile(content []byte) bool {
 }
 
 func init() {
+	watchCmd.Flags().StringVar(&watchOnChange, "on-change", "", "Run this shell command for each changed file once it stops changing, with AEGIS_PATH and AEGIS_ACTION set")
+	watchCmd.Flags().BoolVar(&watchSealOnChange, "seal-on-change", false, "Seal created or modified files in place once they stop changing")
+	watchCmd.Flags().StringVar(&watchPasswordFile, "password-file", "", "Read the --seal-on-change password from the first line of this file")
+	watchCmd.Flags().BoolVar(&watchRecursive, "recursive", true, "Watch subdirectories too; --recursive=false watches only the files directly in the directory")
+	watchCmd.Flags().IntVar(&watchMaxDepth, "max-depth", 0, "Watch files at most this many levels below the directory (1 is the directory's own files); 0 means no limit")
+	watchCmd.Flags().BoolVar(&watchPoll, "poll", false, "Detect changes by rescanning the directory instead of filesystem events (for NFS, SMB and some container mounts)")
+	watchCmd.Flags().DurationVar(&watchPollInterval, "poll-interval", 2*time.Second, "Time between rescans in --poll mode")
+	watchCmd.Flags().IntVar(&watchPreview.lines, "preview-lines", 5, "Lines of a new file shown in the detailed log (0 hides the preview)")
+	watchCmd.Flags().IntVar(&watchPreview.width, "preview-width", 70, "Columns after which lines in the detailed log are truncated")
+	watchCmd.Flags().StringVar(&watchMaxSnapshotSize, "max-snapshot-size", "", "Keep only a hash, not the content, of files larger than this (e.g. 1MB); their changes are logged without a line diff")
+	watchCmd.Flags().StringVar(&watchHashAlgo, "hash-algo", "sha256", "Hash used to detect content changes: sha256, or the faster xxhash or crc32 for large files")
+	watchCmd.Flags().StringVar(&watchMaxLogSize, "max-log-size", "", "Rotate each log to <name>.1 once it exceeds this size (e.g. 10MB)")
+	watchCmd.Flags().StringArrayVar(&watchIncludes, "include", nil, "Only watch files whose name or relative path matches this glob (repeatable, e.g. '*.go')")
+	watchCmd.Flags().StringArrayVar(&watchExcludeDirs, "exclude-dir", nil, "Do not watch directories with this name at any depth, in addition to .git, vendor, node_modules, target, .idea and .vscode (repeatable)")
+	watchCmd.Flags().StringArrayVar(&watchExcludes, "exclude", nil, "Do not watch files and directories matching this glob (repeatable; a trailing / matches directories only)")
+	watchCmd.Flags().StringVar(&watchDiffStyle, "diff-style", "decorated", "Detailed log change rendering: decorated or unified (standard @@ hunks)")
+	watchCmd.Flags().StringVar(&watchSnapshotFile, "snapshot-file", "", "Save file hashes here on exit and, on the next start, report what changed while watch was not running")
+	watchCmd.Flags().StringSliceVar(&watchTextExts, "text-ext", nil, "Always treat files with these extensions as text for previews and diffs (repeatable or comma-separated, e.g. .json,.csv)")
+	watchCmd.Flags().StringSliceVar(&watchBinaryExts, "binary-ext", nil, "Always treat files with these extensions as binary, logging only size and hash changes (repeatable or comma-separated)")
+	watchCmd.Flags().DurationVar(&watchSince, "since", 0, "Before watching, report the files modified within this long (e.g. 30m, 2h), from their modification times")
+	watchCmd.Flags().BoolVar(&watchSummaryOnly, "summary-only", false, "Print event counts every --summary-interval instead of each event (the logs keep full detail)")
+	watchCmd.Flags().BoolVar(&watchCountOnly, "count-only", false, "Report the startup scan, and any --since or --snapshot-file changes, as totals instead of listing each file")
+	watchCmd.Flags().BoolVar(&watchByteOffsets, "byte-offsets", false, "Also report the byte offset where a modified file first differs and the length of the changed span, for binary files and very long lines")
+	watchCmd.Flags().BoolVar(&watchFollow, "follow", false, "Report text files that shrink to new content (truncated or rotated logs) as truncated, instead of diffing them against the old content")
+	watchCmd.Flags().BoolVar(&watchTail, "tail", false, "Print only the new content of modified and added lines, as path:line: text, instead of each event's box (the rest goes to stderr)")
+	watchCmd.Flags().DurationVar(&watchSummaryInterval, "summary-interval", time.Minute, "Time between tallies in --summary-only mode")
+	watchCmd.Flags().StringVar(&watchLogDir, "log-dir", "logs", "Directory the timestamped session log folder is created in; never watched, even inside the watched tree")
+	watchCmd.Flags().StringVar(&watchFormat, "format", "text", "Basic log format: text or json (one JSON object per event)")
 	RootCmd.AddCommand(watchCmd)
 }
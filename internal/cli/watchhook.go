@@ -0,0 +1,100 @@
+package cli
+
+import (
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+)
+
+// hookQuietPeriod is how long a file must go without events before --on-change runs for it, so
+// an editor's burst of writes for one save triggers a single run
+const hookQuietPeriod = 500 * time.Millisecond
+
+// hookEvent is a change waiting out its quiet period before the --on-change command runs for it
+type hookEvent struct {
+	path    string
+	relPath string
+	action  string
+	last    time.Time
+}
+
+// hookQueue holds the changes waiting for --on-change, one per path
+type hookQueue map[string]hookEvent
+
+// add records a change to path. A newer change replaces an older one, except that a file
+// created and then written keeps "created", which says more about it
+func (q hookQueue) add(path, relPath, action string, at time.Time) {
+	if prev, ok := q[path]; ok && prev.action == "created" && action == "modified" {
+		action = prev.action
+	}
+	q[path] = hookEvent{path: path, relPath: relPath, action: action, last: at}
+}
+
+// due removes and returns the changes that have been quiet for hookQuietPeriod, oldest first
+func (q hookQueue) due(now time.Time) []hookEvent {
+	var ready []hookEvent
+	for path, ev := range q {
+		if now.Sub(ev.last) >= hookQuietPeriod {
+			ready = append(ready, ev)
+			delete(q, path)
+		}
+	}
+	sort.Slice(ready, func(i, j int) bool { return ready[i].last.Before(ready[j].last) })
+	return ready
+}
+
+// watchHookEvent is the JSON basic-log entry for one run of the --on-change command
+type watchHookEvent struct {
+	Action    string `json:"action"` // Always "hook"
+	Path      string `json:"path"`
+	Timestamp string `json:"timestamp"`
+	Trigger   string `json:"trigger"` // The action of the change the command ran for
+	ExitCode  int    `json:"exit_code"`
+	Duration  string `json:"duration"`
+	Error     string `json:"error,omitempty"` // Set when the command could not be started
+}
+
+// runHook runs command through the shell for ev, with AEGIS_PATH and AEGIS_ACTION describing the
+// change, and waits for it. Its output goes to out and stderr. The exit code is -1 when the
+// command could not be started or was killed by a signal; err is only set in the first case
+func runHook(command string, ev hookEvent, out io.Writer) (code int, elapsed time.Duration, err error) {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", command)
+	} else {
+		cmd = exec.Command("sh", "-c", command)
+	}
+	cmd.Env = append(hookEnviron(), "AEGIS_PATH="+ev.path, "AEGIS_ACTION="+ev.action, "AEGIS_REL_PATH="+filepath.ToSlash(ev.relPath))
+	cmd.Stdout = out
+	cmd.Stderr = os.Stderr
+
+	start := time.Now()
+	err = cmd.Run()
+	elapsed = time.Since(start)
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode(), elapsed, nil
+	}
+	if err != nil {
+		return -1, elapsed, err
+	}
+	return 0, elapsed, nil
+}
+
+// hookEnviron returns watch's environment without the passwords, which the hook has no use for
+func hookEnviron() []string {
+	var env []string
+	for _, kv := range os.Environ() {
+		if strings.HasPrefix(kv, passwordEnvVar+"=") || strings.HasPrefix(kv, newPasswordEnvVar+"=") {
+			continue
+		}
+		env = append(env, kv)
+	}
+	return env
+}
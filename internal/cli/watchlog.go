@@ -0,0 +1,110 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// rotatingLog is a watch log file that rolls over to <name>.1 once it grows past maxSize
+type rotatingLog struct {
+	file    *os.File
+	path    string
+	header  string // Rewritten at the top of every fresh file
+	written int64  // Bytes written to the current file, tracked instead of statting
+	maxSize int64  // Zero disables rotation
+}
+
+// openRotatingLog opens (or creates) the log at path for appending
+func openRotatingLog(path string, maxSize int64) (*rotatingLog, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	l := &rotatingLog{file: f, path: path, maxSize: maxSize}
+	if info, err := f.Stat(); err == nil {
+		l.written = info.Size()
+	}
+	return l, nil
+}
+
+// writeHeader writes the session header and remembers it for rotated files
+func (l *rotatingLog) writeHeader(header string) {
+	l.header = header
+	l.WriteString(header)
+}
+
+// WriteString appends s, rotating first if it would push the file past maxSize
+func (l *rotatingLog) WriteString(s string) (int, error) {
+	if l.maxSize > 0 && l.written+int64(len(s)) > l.maxSize && l.written > int64(len(l.header)) {
+		if err := l.rotate(); err != nil {
+			warnf("%s Warning: Could not rotate log %s: %v\n", markWarn, l.path, err)
+		}
+	}
+	n, err := l.file.WriteString(s)
+	l.written += int64(n)
+	return n, err
+}
+
+// Write appends p; see WriteString
+func (l *rotatingLog) Write(p []byte) (int, error) {
+	return l.WriteString(string(p))
+}
+
+// rotate closes the current file, moves it to <name>.1 (replacing any older one) and starts
+// a fresh file with the same header
+func (l *rotatingLog) rotate() error {
+	if err := l.file.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(l.path, l.path+".1"); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(l.path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	l.file = f
+	l.written = 0
+	n, err := f.WriteString(l.header)
+	l.written += int64(n)
+	return err
+}
+
+// Sync flushes the current file to disk
+func (l *rotatingLog) Sync() error {
+	return l.file.Sync()
+}
+
+// Close closes the current file
+func (l *rotatingLog) Close() error {
+	return l.file.Close()
+}
+
+// parseByteSize parses sizes such as "10MB", "512K" or "1048576" (binary units)
+func parseByteSize(s string) (int64, error) {
+	units := []struct {
+		suffix string
+		factor int64
+	}{
+		{"GB", 1 << 30}, {"G", 1 << 30},
+		{"MB", 1 << 20}, {"M", 1 << 20},
+		{"KB", 1 << 10}, {"K", 1 << 10},
+		{"B", 1},
+	}
+	value := strings.ToUpper(strings.TrimSpace(s))
+	factor := int64(1)
+	for _, u := range units {
+		if strings.HasSuffix(value, u.suffix) {
+			value = strings.TrimSpace(strings.TrimSuffix(value, u.suffix))
+			factor = u.factor
+			break
+		}
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q (use e.g. 10MB, 512KB or a byte count)", s)
+	}
+	return n * factor, nil
+}
@@ -0,0 +1,236 @@
+package cli
+
+import (
+	"aegis/internal/atomicfile"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// watchStateVersion is the format version written to --snapshot-file
+const watchStateVersion = 1
+
+// watchState is what a watch session last saw of each file, persisted with --snapshot-file so
+// the next session can report what changed while watch was not running. Only hashes, sizes and
+// modification times are kept, never content
+type watchState struct {
+	Version  int                        `json:"version"`
+	Saved    time.Time                  `json:"saved"`
+	HashAlgo string                     `json:"hash_algo,omitempty"` // The --hash-algo of the session; empty (older files) is sha256
+	Files    map[string]watchStateEntry `json:"files"`               // Keyed by slash-separated path relative to the watched directory
+}
+
+// hashAlgo returns the --hash-algo the entries' digests were computed with
+func (st *watchState) hashAlgo() string {
+	if st.HashAlgo == "" {
+		return "sha256"
+	}
+	return st.HashAlgo
+}
+
+type watchStateEntry struct {
+	SHA256  string    `json:"sha256"` // Hex digest in the state's hash algorithm; the key predates --hash-algo
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"`
+}
+
+// offlineChange is a difference between the saved state and the files found at startup
+type offlineChange struct {
+	action  string // "created", "modified" or "removed"
+	relPath string
+	oldSize int64
+	newSize int64
+	modTime time.Time // Zero for removed files
+}
+
+// readWatchState loads a snapshot file written by an earlier session; a missing file returns nil
+func readWatchState(path string) (*watchState, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var st watchState
+	if err := json.Unmarshal(data, &st); err != nil {
+		return nil, fmt.Errorf("%s is not a watch snapshot file: %v", path, err)
+	}
+	if st.Version != watchStateVersion {
+		return nil, fmt.Errorf("%s has unsupported version %d", path, st.Version)
+	}
+	return &st, nil
+}
+
+// writeWatchState saves st to path, replacing the previous snapshot file atomically
+func writeWatchState(path string, st *watchState) error {
+	return atomicfile.Write(path, 0600, func(w io.Writer) error {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(st)
+	})
+}
+
+// state returns the tracker's snapshots as a watchState with paths relative to root. The file
+// at skip (the snapshot file itself, when it lives in the watched tree) is left out
+func (ft *fileTracker) state(root, skip string) *watchState {
+	ft.mu.RLock()
+	defer ft.mu.RUnlock()
+	skipAbs, _ := filepath.Abs(skip)
+	st := &watchState{Version: watchStateVersion, Saved: time.Now(), HashAlgo: ft.hashAlgo, Files: make(map[string]watchStateEntry, len(ft.snapshots))}
+	size := ft.newHash().Size()
+	for path, s := range ft.snapshots {
+		if abs, _ := filepath.Abs(path); abs == skipAbs {
+			continue
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			continue
+		}
+		st.Files[filepath.ToSlash(rel)] = watchStateEntry{SHA256: hex.EncodeToString(s.hash[:size]), Size: s.size, ModTime: s.modTime}
+	}
+	return st
+}
+
+// diffWatchStates lists the files created, modified (by content hash) or removed between prev
+// and cur, sorted by path
+func diffWatchStates(prev, cur *watchState) []offlineChange {
+	var changes []offlineChange
+	for rel, now := range cur.Files {
+		was, ok := prev.Files[rel]
+		switch {
+		case !ok:
+			changes = append(changes, offlineChange{action: "created", relPath: rel, newSize: now.Size, modTime: now.ModTime})
+		case was.SHA256 != now.SHA256:
+			changes = append(changes, offlineChange{action: "modified", relPath: rel, oldSize: was.Size, newSize: now.Size, modTime: now.ModTime})
+		}
+	}
+	for rel, was := range prev.Files {
+		if _, ok := cur.Files[rel]; !ok {
+			changes = append(changes, offlineChange{action: "removed", relPath: rel, oldSize: was.Size})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].relPath < changes[j].relPath })
+	return changes
+}
+
+// logOfflineChanges reports the changes found since the previous session in both logs. Basic
+// log entries use the file's modification time, or started for removals, whose time is unknown
+func logOfflineChanges(changes []offlineChange, prevSaved, started time.Time, detailedLog, basicLog *rotatingLog, jsonLog bool) {
+	msg := fmt.Sprintf("\n┌─── CHANGES SINCE LAST SESSION ──────────────────────────────\n")
+	msg += fmt.Sprintf("│ %s Snapshot saved: %s\n", markTime, prevSaved.Format("2006-01-02 15:04:05"))
+	if len(changes) == 0 {
+		msg += fmt.Sprintf("│ %s No files changed\n", markOK)
+	}
+	for _, c := range changes {
+		switch c.action {
+		case "created":
+			msg += fmt.Sprintf("│ %s Created:  %s (%d bytes)\n", markCreated, filepath.FromSlash(c.relPath), c.newSize)
+		case "modified":
+			msg += fmt.Sprintf("│ %s Modified: %s (size %d → %d bytes)\n", markChanged, filepath.FromSlash(c.relPath), c.oldSize, c.newSize)
+		case "removed":
+			msg += fmt.Sprintf("│ %s Removed:  %s\n", markRemoved, filepath.FromSlash(c.relPath))
+		}
+	}
+	msg += "└─────────────────────────────────────────────────────────────\n\n"
+	if watchCountOnly {
+		fmt.Fprintf(watchBanner, "%s Since last session: %s\n", markStats, countChanges(changes))
+	} else {
+		fmt.Fprint(watchBanner, msg)
+	}
+	detailedLog.WriteString(msg)
+
+	for _, c := range changes {
+		at := c.modTime
+		if at.IsZero() {
+			at = started
+		}
+		if jsonLog {
+			event := newWatchEvent(c.action, c.relPath, at, changeSummary{newSize: int(c.newSize), lineSpec: "-"})
+			event.SinceLastSession = true
+			writeJSONLine(basicLog, event)
+			continue
+		}
+		label := map[string]string{"created": "Created", "modified": "Modified", "removed": "Removed"}[c.action]
+		basicLog.WriteString(fmt.Sprintf("[%s] %s | %s | size %d bytes | since last session\n", label, filepath.FromSlash(c.relPath), at.Format("2006-01-02 15:04:05"), c.newSize))
+	}
+}
+
+// countChanges renders the changes found since the previous session as totals, e.g.
+// "2 created, 5 modified, 0 removed"
+func countChanges(changes []offlineChange) string {
+	counts := make(map[string]int)
+	for _, c := range changes {
+		counts[c.action]++
+	}
+	return fmt.Sprintf("%d created, %d modified, %d removed", counts["created"], counts["modified"], counts["removed"])
+}
+
+// recentChanges lists the files in st modified after cutoff, oldest first. Without a saved
+// state to compare against, a created file cannot be told from a modified one
+func recentChanges(st *watchState, cutoff time.Time) []offlineChange {
+	var changes []offlineChange
+	for rel, e := range st.Files {
+		if e.ModTime.After(cutoff) {
+			changes = append(changes, offlineChange{action: "recent", relPath: rel, newSize: e.Size, modTime: e.ModTime})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		if !changes[i].modTime.Equal(changes[j].modTime) {
+			return changes[i].modTime.Before(changes[j].modTime)
+		}
+		return changes[i].relPath < changes[j].relPath
+	})
+	return changes
+}
+
+// logRecentChanges reports the files changed within since of startup, found by --since from
+// their modification times, in both logs
+func logRecentChanges(changes []offlineChange, since time.Duration, detailedLog, basicLog *rotatingLog, jsonLog bool) {
+	window := shortDuration(since)
+	msg := fmt.Sprintf("\n┌─── CHANGES IN THE LAST %s ", window)
+	if pad := 63 - len([]rune(msg)); pad > 0 { // Lines up with the closing border, after the leading newline
+		msg += strings.Repeat("─", pad)
+	}
+	msg += "\n"
+	if len(changes) == 0 {
+		msg += fmt.Sprintf("│ %s No files changed\n", markOK)
+	}
+	for _, c := range changes {
+		msg += fmt.Sprintf("│ %s %s  %s (%d bytes)\n", markChanged, c.modTime.Format("2006-01-02 15:04:05"), filepath.FromSlash(c.relPath), c.newSize)
+	}
+	msg += "└─────────────────────────────────────────────────────────────\n\n"
+	if watchCountOnly {
+		fmt.Fprintf(watchBanner, "%s Changed in the last %s: %d files\n", markStats, window, len(changes))
+	} else {
+		fmt.Fprint(watchBanner, msg)
+	}
+	detailedLog.WriteString(msg)
+
+	for _, c := range changes {
+		if jsonLog {
+			writeJSONLine(basicLog, newWatchEvent(c.action, c.relPath, c.modTime, changeSummary{newSize: int(c.newSize), lineSpec: "-"}))
+			continue
+		}
+		basicLog.WriteString(fmt.Sprintf("[Recent] %s | %s | size %d bytes | changed in the last %s\n", filepath.FromSlash(c.relPath), c.modTime.Format("2006-01-02 15:04:05"), c.newSize, window))
+	}
+}
+
+// shortDuration renders d without trailing zero units: 2h rather than 2h0m0s
+func shortDuration(d time.Duration) string {
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
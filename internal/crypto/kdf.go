@@ -0,0 +1,241 @@
+package crypto
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"sync"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// kdfScrypt identifies scrypt as the key derivation function in the header.
+const kdfScrypt byte = 1
+
+// keyfileDomain separates the password from the key file digest in the scrypt input of
+// two-factor files.
+const keyfileDomain = "\x00aegis keyfile v1\x00"
+
+// keyCheckDomain is the message the key check value of v9+ headers is computed over.
+const keyCheckDomain = "aegis key check v1"
+
+// ErrKeyfileRequired reports a file sealed with a key file (FlagKeyfile) opened with a
+// KeyCache that has none.
+var ErrKeyfileRequired = errors.New("sealed with a key file, which was not supplied")
+
+// KDFParams are the scrypt cost parameters and derived key length.
+type KDFParams struct {
+	N, R, P int
+	KeyLen  int
+}
+
+// DefaultKDFParams are used for new files unless overridden, and for every file sealed
+// before v5, which did not record its parameters.
+var DefaultKDFParams = KDFParams{N: 1 << 15, R: 8, P: 1, KeyLen: 32}
+
+// Validate rejects parameters scrypt or AES cannot use, and values so large that a
+// crafted header could exhaust memory.
+func (k KDFParams) Validate() error {
+	if k.N < 2 || k.N&(k.N-1) != 0 {
+		return fmt.Errorf("scrypt N must be a power of two greater than 1, got %d", k.N)
+	}
+	if k.R < 1 || k.P < 1 || uint64(k.R)*uint64(k.P) >= 1<<30 {
+		return fmt.Errorf("invalid scrypt r=%d p=%d", k.R, k.P)
+	}
+	if uint64(128)*uint64(k.N)*uint64(k.R) > 1<<32 {
+		return fmt.Errorf("scrypt N=%d r=%d would need more than 4 GB of memory", k.N, k.R)
+	}
+	if k.KeyLen != 16 && k.KeyLen != 24 && k.KeyLen != 32 {
+		return fmt.Errorf("invalid key length %d", k.KeyLen)
+	}
+	return nil
+}
+
+// MinKDFParams is the weakest scrypt cost new files should be sealed with: half the default
+// N, and the 16 MB of memory that N takes at the default r.
+var MinKDFParams = KDFParams{N: 1 << 14, R: 8, P: 1, KeyLen: 32}
+
+// CheckStrength reports parameters below MinKDFParams. Unlike Validate it is a policy for
+// sealing, not a limit of scrypt, so it is never applied to files being opened.
+func (k KDFParams) CheckStrength() error {
+	if k.N < MinKDFParams.N {
+		return fmt.Errorf("scrypt N=%d is below the minimum of %d", k.N, MinKDFParams.N)
+	}
+	if uint64(k.N)*uint64(k.R) < uint64(MinKDFParams.N)*uint64(MinKDFParams.R) {
+		return fmt.Errorf("scrypt N=%d r=%d uses %d MB of memory, below the minimum of %d MB",
+			k.N, k.R, 128*uint64(k.N)*uint64(k.R)>>20, 128*uint64(MinKDFParams.N)*uint64(MinKDFParams.R)>>20)
+	}
+	return nil
+}
+
+// derivedKey is an AES-GCM instance and the key check value of the key behind it.
+type derivedKey struct {
+	gcm   cipher.AEAD
+	check []byte
+}
+
+// Key bundles a derived AES-GCM instance with the salt and KDF parameters recorded in each header.
+type Key struct {
+	derivedKey
+	salt    []byte
+	kdf     KDFParams
+	keyfile bool // Derived with a key file; files sealed with it are marked FlagKeyfile.
+}
+
+// KDF returns the parameters the key was derived with.
+func (k *Key) KDF() KDFParams {
+	return k.kdf
+}
+
+// NewKey generates a fresh random salt and derives the matching AES-GCM instance.
+func NewKey(password string, kdf KDFParams) (*Key, error) {
+	return NewKeyfileKey(password, nil, kdf)
+}
+
+// NewKeyfileKey is NewKey for two-factor sealing: keyfile is the content of a key file, and
+// files sealed with the key open only with both it and the password. A nil keyfile is the same
+// as NewKey.
+func NewKeyfileKey(password string, keyfile []byte, kdf KDFParams) (*Key, error) {
+	salt := make([]byte, SaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("failed to generate salt: %v", err)
+	}
+	dk, err := deriveKey(kdfInput(password, keyfileDigest(keyfile)), salt, kdf)
+	if err != nil {
+		return nil, err
+	}
+	return &Key{derivedKey: *dk, salt: salt, kdf: kdf, keyfile: keyfile != nil}, nil
+}
+
+// KeyCache memoizes derived AES-GCM instances by salt and KDF parameters, so files
+// sealed in the same session (which share a salt) only run scrypt once. It is safe for
+// concurrent use; callers waiting on the same salt share one derivation, while keys for
+// different salts are derived in parallel.
+type KeyCache struct {
+	password string
+	keyfile  []byte // SHA-256 of the key file; nil when none was given.
+	mu       sync.Mutex
+	keys     map[string]*cachedKey
+}
+
+// cachedKey is a KeyCache entry: the derivation runs once, outside the cache lock, and every
+// caller for the same salt and parameters gets its result.
+type cachedKey struct {
+	once sync.Once
+	dk   *derivedKey
+	err  error
+}
+
+// NewKeyCache returns an empty cache for the given password.
+func NewKeyCache(password string) *KeyCache {
+	return NewKeyfileKeyCache(password, nil)
+}
+
+// NewKeyfileKeyCache returns an empty cache for the password and the content of a key file.
+// Files sealed with a key file use both; files sealed without one still open with the
+// password alone, so a tree sealed partly each way can be opened in one pass.
+func NewKeyfileKeyCache(password string, keyfile []byte) *KeyCache {
+	return &KeyCache{password: password, keyfile: keyfileDigest(keyfile), keys: make(map[string]*cachedKey)}
+}
+
+// get returns the key for the salt and parameters, deriving it on first use. keyfile reports
+// whether the file was sealed with a key file.
+func (c *KeyCache) get(salt []byte, kdf KDFParams, keyfile bool) (*derivedKey, error) {
+	if keyfile && c.keyfile == nil {
+		return nil, ErrKeyfileRequired
+	}
+	id := fmt.Sprintf("%x/%d/%d/%d/%d/%v", salt, kdf.N, kdf.R, kdf.P, kdf.KeyLen, keyfile)
+	c.mu.Lock()
+	entry, ok := c.keys[id]
+	if !ok {
+		entry = &cachedKey{}
+		c.keys[id] = entry
+	}
+	c.mu.Unlock()
+	entry.once.Do(func() { // Only callers for this id wait here; scrypt runs without c.mu held.
+		digest := c.keyfile
+		if !keyfile {
+			digest = nil
+		}
+		entry.dk, entry.err = deriveKey(kdfInput(c.password, digest), salt, kdf)
+	})
+	return entry.dk, entry.err
+}
+
+// keyfileDigest returns the SHA-256 of a key file's content, or nil for no key file.
+func keyfileDigest(keyfile []byte) []byte {
+	if keyfile == nil {
+		return nil
+	}
+	sum := sha256.Sum256(keyfile)
+	return sum[:]
+}
+
+// kdfInput returns the scrypt password: the password alone, or with a key file digest, the
+// password, keyfileDomain and the digest. The digest has a fixed length and comes last, so no
+// other password and key file give the same input.
+func kdfInput(password string, digest []byte) []byte {
+	input := []byte(password)
+	if digest != nil {
+		input = append(append(input, keyfileDomain...), digest...)
+	}
+	return input
+}
+
+// deriveKey derives the AES key from the scrypt input and salt and returns the AES-GCM
+// instance used to seal and open file contents, with the key's check value.
+func deriveKey(input, salt []byte, kdf KDFParams) (*derivedKey, error) {
+	key, err := scrypt.Key(input, salt, kdf.N, kdf.R, kdf.P, kdf.KeyLen)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive key: %v", err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher block: %v", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GCM: %v", err)
+	}
+	return &derivedKey{gcm: gcm, check: keyCheck(key)}, nil
+}
+
+// keyCheck returns the key check value recorded in v9+ headers: a truncated HMAC-SHA256 of a
+// fixed message under the AES key. It shows whether a derived key is the right one without
+// revealing anything about it, and costs an attacker nothing the scrypt run does not already.
+func keyCheck(key []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(keyCheckDomain))
+	return mac.Sum(nil)[:KeyCheckSize]
+}
+
+// NewMACKey derives a key for authenticating data that is not a sealed file, such as a checksum
+// manifest, from the password and a fresh random salt. It returns the key and the salt to record.
+func NewMACKey(password, purpose string, kdf KDFParams) (key, salt []byte, err error) {
+	salt = make([]byte, SaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, nil, fmt.Errorf("failed to generate salt: %v", err)
+	}
+	key, err = MACKey(password, purpose, salt, kdf)
+	return key, salt, err
+}
+
+// MACKey derives the authentication key for purpose from the password and a recorded salt. The
+// scrypt output is bound to purpose with HMAC-SHA256, so it never doubles as an encryption key.
+// The parameters are validated first, since they are read from the data being checked.
+func MACKey(password, purpose string, salt []byte, kdf KDFParams) ([]byte, error) {
+	if err := kdf.Validate(); err != nil {
+		return nil, err
+	}
+	master, err := scrypt.Key([]byte(password), salt, kdf.N, kdf.R, kdf.P, kdf.KeyLen)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive key: %v", err)
+	}
+	mac := hmac.New(sha256.New, master)
+	mac.Write([]byte(purpose))
+	return mac.Sum(nil), nil
+}
@@ -0,0 +1,165 @@
+package crypto
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"crypto/hmac"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// Payload holds the decrypted metadata of a sealed file and a reader over its content.
+type Payload struct {
+	Metadata
+	Version    byte
+	KDF        KDFParams // Key derivation parameters the file was sealed with.
+	Salt       []byte    // scrypt salt; files sealed in one session share it unless sealed with a salt each.
+	Compressed bool      // Content was DEFLATE-compressed before encryption (already inflated in Content).
+	Keyfile    bool      // Sealed with a key file (FlagKeyfile), so opening it took one.
+	HasExt     bool      // False when the extension terminator is missing (old format or corruption).
+	Content    io.Reader // Remaining plaintext; chunked files are authenticated as they are read.
+
+	stored   io.Reader // Content before inflation; the same reader as Content when not compressed.
+	overhead int64     // Bytes of the sealed file that are not stored content: header, tags, metadata.
+}
+
+// Stored returns the content exactly as sealed, still DEFLATE-compressed when Compressed is
+// set, and its length, given the size of the sealed file. It lets a file be sealed again
+// without inflating it, by passing the reader and length to Seal with Compressed. Read either
+// Stored or Content, not both.
+func (p *Payload) Stored(sealedSize int64) (io.Reader, int64) {
+	return p.stored, sealedSize - p.overhead
+}
+
+// Open parses the header of a sealed file in any supported format, looks up or derives the key
+// and decrypts the embedded metadata. name is the file's base name, which v7+ files authenticate.
+// Legacy whole-file formats (v0-v3) are read into memory; chunked files (v4+) are decrypted
+// lazily as the returned content is consumed. A wrong password or tampered data is reported
+// as ErrDecryptFailed (from v9, as ErrWrongPassword or ErrCorrupted, which wrap it), and a file
+// sealed with a key file the cache lacks as ErrKeyfileRequired.
+func Open(r io.Reader, name string, keys *KeyCache) (*Payload, error) {
+	br := bufio.NewReader(r)
+	prefix, _ := br.Peek(HeaderSize)
+	version, err := DetectFormat(prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	var plaintext io.Reader
+	var compressed bool     // Set when the header marks the content as DEFLATE-compressed (v6+).
+	var salt []byte         // From the stream header (v4+), or in front of the legacy ciphertext.
+	var overhead int64      // Sealed bytes around the plaintext; the metadata length is added below.
+	var keyfile bool        // Set when the header marks a two-factor file (v6+).
+	kdf := DefaultKDFParams // Pre-v5 files used the hardcoded defaults.
+	if version >= FormatV4 {
+		br.Discard(HeaderSize)
+		h, err := ReadStreamHeader(br, version, name)
+		if err != nil {
+			return nil, err
+		}
+		dk, err := keys.get(h.Salt, h.KDF, h.Flags&FlagKeyfile != 0)
+		if err != nil {
+			return nil, err
+		}
+		keyChecked := h.Version >= FormatV9
+		if keyChecked && !hmac.Equal(dk.check, h.KeyCheck) {
+			return nil, ErrWrongPassword
+		}
+		plaintext = newChunkReader(br, dk.gcm, h, keyChecked)
+		compressed = h.Flags&FlagCompressed != 0
+		keyfile = h.Flags&FlagKeyfile != 0
+		kdf, salt = h.KDF, h.Salt
+		overhead = int64(len(h.Marshal())) + int64(h.ChunkCount)*int64(dk.gcm.Overhead())
+	} else {
+		data, err := io.ReadAll(br)
+		if err != nil {
+			return nil, err
+		}
+		if version != FormatLegacy {
+			data = data[HeaderSize:] // v1-v3 share the legacy layout once the header is removed.
+		}
+		if len(data) < SaltSize+NonceSize { // Minimum length: 16 bytes salt + 12 bytes nonce.
+			return nil, fmt.Errorf("too short/corrupted")
+		}
+		salt = data[:SaltSize]
+		dk, err := keys.get(salt, kdf, false)
+		if err != nil {
+			return nil, err
+		}
+		nonce := data[SaltSize : SaltSize+NonceSize]
+		opened, err := dk.gcm.Open(nil, nonce, data[SaltSize+NonceSize:], nil)
+		if err != nil {
+			return nil, ErrDecryptFailed
+		}
+		plaintext = bytes.NewReader(opened)
+		overhead = int64(len(data) - len(opened))
+		if version != FormatLegacy {
+			overhead += int64(HeaderSize)
+		}
+	}
+
+	pr := bufio.NewReader(plaintext)
+	payload := &Payload{Version: version, KDF: kdf, Salt: salt, Compressed: compressed, Keyfile: keyfile, Metadata: Metadata{Mode: defaultFileMode}}
+
+	// --- PERMISSIONS: Recover Mode (v2+) ---
+	if version >= FormatV2 {
+		var mode [modeSize]byte
+		if _, err := io.ReadFull(pr, mode[:]); err != nil {
+			return nil, metadataError(err, "missing permissions")
+		}
+		payload.Mode = os.FileMode(binary.BigEndian.Uint32(mode[:])).Perm()
+		overhead += modeSize
+	}
+
+	// --- TIMESTAMPS: Recover Modification Time (v3+) ---
+	if version >= FormatV3 {
+		var mtime [mtimeSize]byte
+		if _, err := io.ReadFull(pr, mtime[:]); err != nil {
+			return nil, metadataError(err, "missing modification time")
+		}
+		payload.ModTime = time.Unix(0, int64(binary.BigEndian.Uint64(mtime[:])))
+		overhead += mtimeSize
+	}
+
+	// --- FILENAMELOGIC: Recover Extension ---
+	ext, err := pr.ReadString(0x00) // Reads up to and including the null terminator.
+	switch {
+	case err == io.EOF: // No terminator: hand back everything that was read as content.
+		payload.Content = strings.NewReader(ext)
+		payload.stored = payload.Content
+	case err != nil:
+		return nil, err
+	default:
+		payload.Ext = strings.TrimSuffix(ext, "\x00")
+		payload.HasExt = true
+		overhead += int64(len(ext))
+		if version >= FormatV8 { // --- FILENAMELOGIC: Recover Full Name (v8+) ---
+			name, err := pr.ReadString(0x00)
+			if err != nil {
+				return nil, metadataError(err, "missing original name")
+			}
+			payload.Name = strings.TrimSuffix(name, "\x00")
+			overhead += int64(len(name))
+		}
+		payload.Content, payload.stored = pr, pr
+		if compressed { // Inflates after GCM has authenticated each chunk.
+			payload.Content = flate.NewReader(pr)
+		}
+	}
+	payload.overhead = overhead
+	return payload, nil
+}
+
+// metadataError keeps authentication failures intact and reports a short read as malformed metadata.
+func metadataError(err error, what string) error {
+	if errors.Is(err, ErrDecryptFailed) {
+		return err
+	}
+	return errors.New(what)
+}
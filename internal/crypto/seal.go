@@ -0,0 +1,58 @@
+package crypto
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// Metadata is the encrypted information stored in front of a file's content.
+type Metadata struct {
+	Mode    os.FileMode
+	ModTime time.Time // Zero when the format does not carry a timestamp.
+	Ext     string    // Original extension, including the dot (e.g. ".txt").
+	Name    string    // Original base name (e.g. "backup.tar.gz"); empty when the format does not carry it.
+}
+
+// encode builds the payload prefix stored in front of the file content:
+// [mode (4)][mtime (8)][ext][0x00][name][0x00].
+func (m Metadata) encode() []byte {
+	meta := binary.BigEndian.AppendUint32(nil, uint32(m.Mode.Perm()))        // Prefixes the original permission bits.
+	meta = binary.BigEndian.AppendUint64(meta, uint64(m.ModTime.UnixNano())) // Adds the original mtime.
+	meta = append(meta, m.Ext...)                                            // Adds the extension after the metadata.
+	meta = append(meta, 0x00)                                                // Null terminator separates extension
+	meta = append(meta, m.Name...)                                           // Adds the full original name (v8+).
+	return append(meta, 0x00)
+}
+
+// Seal writes a sealed file in the current format to w: the header, then the metadata followed
+// by size bytes of content, encrypted in chunks with key. name is the sealed file's base name,
+// which is authenticated, so the output only opens under that name. compressed records that the
+// content was already DEFLATE-compressed by the caller; Open inflates it again. meta.Name must
+// not contain a NUL byte.
+func Seal(w io.Writer, name string, meta Metadata, content io.Reader, size int64, compressed bool, key *Key) error {
+	// Random base nonce; each chunk XORs its index into it.
+	nonce := make([]byte, NonceSize)
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return fmt.Errorf("failed to generate nonce: %v", err)
+	}
+	var flags byte
+	if compressed {
+		flags |= FlagCompressed
+	}
+	if key.keyfile {
+		flags |= FlagKeyfile
+	}
+
+	prefix := meta.encode()
+	plaintext := io.MultiReader(bytes.NewReader(prefix), content)                                        // Metadata followed by the file content.
+	total := int64(len(prefix)) + size                                                                   // Exact payload size, used to frame the chunks.
+	header := newStreamHeader(total, defaultChunkSize, key.kdf, flags, name, key.salt, nonce, key.check) // Records chunk layout and KDF parameters; binds the file name.
+
+	// Final file format: [Header] + [Chunk 0 + Auth Tag] + [Chunk 1 + Auth Tag] + ...
+	return sealStream(w, plaintext, total, key.gcm, header)
+}
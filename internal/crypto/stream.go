@@ -0,0 +1,297 @@
+package crypto
+
+import (
+	"crypto/cipher"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Chunked framing (v4 and later):
+//
+//	[magic "AEGS" (4)][version (1)][chunk size (4)][chunk count (8)][salt (16)][base nonce (12)]
+//	[chunk 0 ciphertext + tag][chunk 1 ciphertext + tag]...
+//
+// From v5 the KDF parameters follow the chunk count:
+//
+//	[kdf id (1)][N (4)][r (4)][p (4)][key length (1)]
+//
+// From v6 a flags byte follows the KDF block. Bit 0 (FlagCompressed) means the content after
+// the metadata was DEFLATE-compressed before encryption. Bit 1 (FlagKeyfile) means the key was
+// derived from the password and a key file together; see kdfInput.
+//
+// From v9 a key check value follows the base nonce:
+//
+//	[key check (16)]
+//
+// It is derived from the AES key (see keyCheck), so a reader can tell whether the key it derived
+// is the one the file was sealed with before touching the chunks. A mismatch is reported as
+// ErrWrongPassword; a chunk that fails with a matching key as ErrCorrupted.
+//
+// From v7 the associated data is the header followed by the sealed file's base name (e.g.
+// "plan.aegis"). The name is not stored; the reader supplies it from the path it opened, so a
+// file that was renamed, or swapped with another, fails authentication.
+//
+// The payload is split into chunks of chunkSize bytes (the last one may be shorter) and each
+// chunk is sealed on its own. Chunk i uses the base nonce with i XORed into its last 8 bytes,
+// and every chunk authenticates the full header as associated data, so reordering, truncation,
+// or tampering with the chunk count is detected.
+const (
+	defaultChunkSize = 64 * 1024        // Plaintext bytes per chunk written by seal.
+	maxChunkSize     = 16 * 1024 * 1024 // Upper bound accepted when reading, to cap memory use.
+	NonceSize        = 12               // Standard AES-GCM nonce size.
+	GCMTagSize       = 16               // AES-GCM authentication tag appended to every ciphertext.
+
+	kdfBlockSize = 1 + 4 + 4 + 4 + 1 // KDF id, N, r, p and key length (v5+).
+	flagsSize    = 1                 // Header flags byte (v6+).
+	KeyCheckSize = 16                // Key check value (v9+).
+
+	FlagCompressed byte = 1 << 0 // Content is DEFLATE-compressed (v6+).
+	FlagKeyfile    byte = 1 << 1 // Key derived with a key file as well as the password (v6+).
+)
+
+// ErrDecryptFailed reports an authentication failure: a wrong password or a corrupted file.
+// Files from v9 on report which of the two it is, with ErrWrongPassword or ErrCorrupted; both
+// wrap ErrDecryptFailed, so checks against it keep matching.
+var ErrDecryptFailed = errors.New("decryption failed")
+
+// ErrWrongPassword reports a file whose key check value does not match the derived key: the
+// password (or key file) is not the one it was sealed with, or the header itself is damaged.
+var ErrWrongPassword = fmt.Errorf("%w: wrong password", ErrDecryptFailed)
+
+// ErrCorrupted reports a file whose key check value matches, so the password is right, but
+// whose data fails authentication: it was corrupted, tampered with, or renamed.
+var ErrCorrupted = fmt.Errorf("%w: file corrupted or renamed", ErrDecryptFailed)
+
+// StreamHeader describes the chunked framing of a sealed file.
+type StreamHeader struct {
+	Version    byte
+	ChunkSize  uint32
+	ChunkCount uint64
+	KDF        KDFParams
+	Flags      byte // Header flags (v6+); zero for older versions.
+	Salt       []byte
+	Nonce      []byte // Base nonce; chunk i uses nonce XOR i.
+	KeyCheck   []byte // Key check value (v9+); nil for older versions.
+	name       string // Sealed file's base name, authenticated but not stored (v7+).
+}
+
+// newStreamHeader computes the framing for a payload of the given total size, bound to the
+// sealed file's base name.
+func newStreamHeader(total int64, chunkSize uint32, kdf KDFParams, flags byte, name string, salt, nonce, check []byte) *StreamHeader {
+	count := (uint64(total) + uint64(chunkSize) - 1) / uint64(chunkSize)
+	return &StreamHeader{
+		Version:    CurrentFormat,
+		ChunkSize:  chunkSize,
+		ChunkCount: count,
+		KDF:        kdf,
+		Flags:      flags,
+		name:       name,
+		Salt:       salt,
+		Nonce:      nonce,
+		KeyCheck:   check,
+	}
+}
+
+// Marshal encodes the header exactly as it is written to disk.
+func (h *StreamHeader) Marshal() []byte {
+	buf := encodeHeader(h.Version)
+	buf = binary.BigEndian.AppendUint32(buf, h.ChunkSize)
+	buf = binary.BigEndian.AppendUint64(buf, h.ChunkCount)
+	if h.Version >= FormatV5 {
+		buf = append(buf, kdfScrypt)
+		buf = binary.BigEndian.AppendUint32(buf, uint32(h.KDF.N))
+		buf = binary.BigEndian.AppendUint32(buf, uint32(h.KDF.R))
+		buf = binary.BigEndian.AppendUint32(buf, uint32(h.KDF.P))
+		buf = append(buf, byte(h.KDF.KeyLen))
+	}
+	if h.Version >= FormatV6 {
+		buf = append(buf, h.Flags)
+	}
+	buf = append(buf, h.Salt...)
+	buf = append(buf, h.Nonce...)
+	if h.Version >= FormatV9 {
+		buf = append(buf, h.KeyCheck...)
+	}
+	return buf
+}
+
+// associatedData returns the bytes every chunk authenticates: the header as written and,
+// from v7, the sealed file's name. The header has a fixed length, so the two cannot be confused.
+func (h *StreamHeader) associatedData() []byte {
+	aad := h.Marshal()
+	if h.Version >= FormatV7 {
+		aad = append(aad, h.name...)
+	}
+	return aad
+}
+
+// ReadStreamHeader reads the chunked header that follows the magic and version bytes. name is
+// the base name the file was opened under; it only matters from v7.
+func ReadStreamHeader(r io.Reader, version byte, name string) (*StreamHeader, error) {
+	size := 4 + 8 + SaltSize + NonceSize
+	if version >= FormatV5 {
+		size += kdfBlockSize
+	}
+	if version >= FormatV6 {
+		size += flagsSize
+	}
+	if version >= FormatV9 {
+		size += KeyCheckSize
+	}
+	buf := make([]byte, size)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return nil, fmt.Errorf("truncated header")
+	}
+	h := &StreamHeader{
+		Version:    version,
+		ChunkSize:  binary.BigEndian.Uint32(buf[0:4]),
+		ChunkCount: binary.BigEndian.Uint64(buf[4:12]),
+		KDF:        DefaultKDFParams, // v4 files were always sealed with the defaults.
+		name:       name,
+	}
+	rest := buf[12:]
+	if version >= FormatV5 {
+		if rest[0] != kdfScrypt {
+			return nil, fmt.Errorf("unsupported KDF id %d", rest[0])
+		}
+		h.KDF = KDFParams{
+			N:      int(binary.BigEndian.Uint32(rest[1:5])),
+			R:      int(binary.BigEndian.Uint32(rest[5:9])),
+			P:      int(binary.BigEndian.Uint32(rest[9:13])),
+			KeyLen: int(rest[13]),
+		}
+		if err := h.KDF.Validate(); err != nil {
+			return nil, err
+		}
+		rest = rest[kdfBlockSize:]
+	}
+	if version >= FormatV6 {
+		h.Flags = rest[0]
+		if h.Flags&^(FlagCompressed|FlagKeyfile) != 0 {
+			return nil, fmt.Errorf("unsupported header flags %#x", h.Flags)
+		}
+		rest = rest[flagsSize:]
+	}
+	h.Salt = rest[:SaltSize]
+	h.Nonce = rest[SaltSize : SaltSize+NonceSize]
+	if version >= FormatV9 {
+		h.KeyCheck = rest[SaltSize+NonceSize:]
+	}
+	if h.ChunkSize == 0 || h.ChunkSize > maxChunkSize {
+		return nil, fmt.Errorf("invalid chunk size %d", h.ChunkSize)
+	}
+	if h.ChunkCount == 0 {
+		return nil, fmt.Errorf("invalid chunk count 0")
+	}
+	return h, nil
+}
+
+// chunkNonce derives the nonce for chunk i from the base nonce.
+func chunkNonce(base []byte, i uint64) []byte {
+	nonce := make([]byte, len(base))
+	copy(nonce, base)
+	counter := binary.BigEndian.Uint64(nonce[len(nonce)-8:])
+	binary.BigEndian.PutUint64(nonce[len(nonce)-8:], counter^i)
+	return nonce
+}
+
+// sealStream writes the header followed by the chunked encryption of exactly total bytes from r.
+func sealStream(w io.Writer, r io.Reader, total int64, aead cipher.AEAD, h *StreamHeader) error {
+	if _, err := w.Write(h.Marshal()); err != nil {
+		return err
+	}
+	aad := h.associatedData()
+
+	buf := make([]byte, h.ChunkSize)
+	var sealed []byte
+	remaining := total
+	for i := uint64(0); i < h.ChunkCount; i++ {
+		n := int64(h.ChunkSize)
+		if remaining < n {
+			n = remaining
+		}
+		if _, err := io.ReadFull(r, buf[:n]); err != nil {
+			return fmt.Errorf("failed to read chunk %d: %w (file changed while sealing?)", i, err)
+		}
+		sealed = aead.Seal(sealed[:0], chunkNonce(h.Nonce, i), buf[:n], aad)
+		if _, err := w.Write(sealed); err != nil {
+			return err
+		}
+		remaining -= n
+	}
+	return nil
+}
+
+// chunkReader decrypts a chunked stream, verifying each chunk before returning its plaintext.
+type chunkReader struct {
+	r     io.Reader
+	aead  cipher.AEAD
+	h     *StreamHeader
+	aad   []byte
+	fail  error  // Wrapped into authentication failures: ErrCorrupted once the key is known to be right.
+	next  uint64 // Index of the next chunk to decrypt.
+	buf   []byte // Ciphertext scratch buffer.
+	plain []byte // Decrypted bytes not yet returned to the caller.
+}
+
+// newChunkReader returns a reader over the plaintext of the chunks that follow the header.
+// keyChecked reports that the header's key check value matched, so a chunk that fails to
+// authenticate is reported as ErrCorrupted rather than the ambiguous ErrDecryptFailed.
+func newChunkReader(r io.Reader, aead cipher.AEAD, h *StreamHeader, keyChecked bool) *chunkReader {
+	fail := ErrDecryptFailed
+	if keyChecked {
+		fail = ErrCorrupted
+	}
+	return &chunkReader{
+		r:    r,
+		aead: aead,
+		h:    h,
+		aad:  h.associatedData(),
+		fail: fail,
+		buf:  make([]byte, int(h.ChunkSize)+aead.Overhead()),
+	}
+}
+
+func (c *chunkReader) Read(p []byte) (int, error) {
+	for len(c.plain) == 0 {
+		if c.next == c.h.ChunkCount {
+			// All declared chunks were read; anything left over means the file was tampered with.
+			var extra [1]byte
+			if n, _ := c.r.Read(extra[:]); n > 0 {
+				return 0, fmt.Errorf("%w: unexpected data after final chunk", c.fail)
+			}
+			return 0, io.EOF
+		}
+		if err := c.readChunk(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, c.plain)
+	c.plain = c.plain[n:]
+	return n, nil
+}
+
+// readChunk reads and authenticates the next chunk.
+func (c *chunkReader) readChunk() error {
+	last := c.next == c.h.ChunkCount-1
+	n, err := io.ReadFull(c.r, c.buf)
+	switch {
+	case err == io.ErrUnexpectedEOF && last:
+		// The final chunk is allowed to be shorter than the chunk size.
+	case err != nil:
+		return fmt.Errorf("%w: chunk %d is truncated", c.fail, c.next)
+	}
+	if n <= c.aead.Overhead() {
+		return fmt.Errorf("%w: chunk %d is truncated", c.fail, c.next)
+	}
+
+	plain, err := c.aead.Open(c.buf[:0], chunkNonce(c.h.Nonce, c.next), c.buf[:n], c.aad)
+	if err != nil {
+		return fmt.Errorf("%w: chunk %d failed authentication", c.fail, c.next)
+	}
+	c.plain = plain
+	c.next++
+	return nil
+}
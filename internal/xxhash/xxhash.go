@@ -0,0 +1,136 @@
+// Package xxhash implements the 64-bit xxHash (XXH64) non-cryptographic hash with seed 0. It is
+// much faster than SHA-256 and is meant for change detection only, never for integrity.
+package xxhash
+
+import (
+	"encoding/binary"
+	"hash"
+	"math/bits"
+)
+
+const (
+	prime1 uint64 = 11400714785074694791
+	prime2 uint64 = 14029467366897019727
+	prime3 uint64 = 1609587929392839161
+	prime4 uint64 = 9650029242287828579
+	prime5 uint64 = 2870177450012600261
+)
+
+// Size is the length of an XXH64 checksum in bytes.
+const Size = 8
+
+// digest is a streaming XXH64 state. Input is consumed in 32-byte stripes across four lanes;
+// up to one stripe is buffered between writes.
+type digest struct {
+	v1, v2, v3, v4 uint64
+	total          uint64
+	mem            [32]byte
+	n              int // Bytes buffered in mem.
+}
+
+// New returns a hash.Hash64 computing XXH64. Its Sum appends the checksum in big-endian order.
+func New() hash.Hash64 {
+	d := new(digest)
+	d.Reset()
+	return d
+}
+
+// Sum64 returns the XXH64 checksum of b.
+func Sum64(b []byte) uint64 {
+	d := New()
+	d.Write(b)
+	return d.Sum64()
+}
+
+func (d *digest) Reset() {
+	var seed uint64 // Always 0; a variable, so the lane setup wraps like the reference instead of overflowing as constants.
+	d.v1 = seed + prime1 + prime2
+	d.v2 = seed + prime2
+	d.v3 = seed
+	d.v4 = seed - prime1
+	d.total = 0
+	d.n = 0
+}
+
+func (d *digest) Size() int      { return Size }
+func (d *digest) BlockSize() int { return 32 }
+
+func (d *digest) Write(b []byte) (int, error) {
+	n := len(b)
+	d.total += uint64(n)
+
+	if d.n+len(b) < 32 { // Not enough for a stripe yet.
+		d.n += copy(d.mem[d.n:], b)
+		return n, nil
+	}
+	if d.n > 0 { // Completes the buffered stripe first.
+		c := copy(d.mem[d.n:], b)
+		d.stripe(d.mem[:])
+		b = b[c:]
+		d.n = 0
+	}
+	for ; len(b) >= 32; b = b[32:] {
+		d.stripe(b)
+	}
+	d.n = copy(d.mem[:], b)
+	return n, nil
+}
+
+// stripe mixes one 32-byte stripe into the four lanes.
+func (d *digest) stripe(b []byte) {
+	d.v1 = round(d.v1, binary.LittleEndian.Uint64(b[0:8]))
+	d.v2 = round(d.v2, binary.LittleEndian.Uint64(b[8:16]))
+	d.v3 = round(d.v3, binary.LittleEndian.Uint64(b[16:24]))
+	d.v4 = round(d.v4, binary.LittleEndian.Uint64(b[24:32]))
+}
+
+func (d *digest) Sum(b []byte) []byte {
+	return binary.BigEndian.AppendUint64(b, d.Sum64())
+}
+
+func (d *digest) Sum64() uint64 {
+	var h uint64
+	if d.total >= 32 {
+		h = bits.RotateLeft64(d.v1, 1) + bits.RotateLeft64(d.v2, 7) + bits.RotateLeft64(d.v3, 12) + bits.RotateLeft64(d.v4, 18)
+		h = mergeRound(h, d.v1)
+		h = mergeRound(h, d.v2)
+		h = mergeRound(h, d.v3)
+		h = mergeRound(h, d.v4)
+	} else {
+		h = d.v3 + prime5 // v3 still holds the seed.
+	}
+	h += d.total
+
+	b := d.mem[:d.n]
+	for ; len(b) >= 8; b = b[8:] {
+		h ^= round(0, binary.LittleEndian.Uint64(b))
+		h = bits.RotateLeft64(h, 27)*prime1 + prime4
+	}
+	if len(b) >= 4 {
+		h ^= uint64(binary.LittleEndian.Uint32(b)) * prime1
+		h = bits.RotateLeft64(h, 23)*prime2 + prime3
+		b = b[4:]
+	}
+	for _, c := range b {
+		h ^= uint64(c) * prime5
+		h = bits.RotateLeft64(h, 11) * prime1
+	}
+
+	h ^= h >> 33
+	h *= prime2
+	h ^= h >> 29
+	h *= prime3
+	h ^= h >> 32
+	return h
+}
+
+func round(acc, input uint64) uint64 {
+	acc += input * prime2
+	acc = bits.RotateLeft64(acc, 31)
+	return acc * prime1
+}
+
+func mergeRound(acc, val uint64) uint64 {
+	acc ^= round(0, val)
+	return acc*prime1 + prime4
+}
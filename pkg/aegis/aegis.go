@@ -0,0 +1,112 @@
+// Package aegis seals and unseals files and directories from Go programs, writing the same
+// sealed files as the aegis command line tool: each file is encrypted with AES-256-GCM under a
+// key derived from a password with scrypt, and restores to its original name, permissions and
+// modification time.
+//
+// SealFile, UnsealFile, SealDir and UnsealDir take a password and do the whole job. Programs
+// that seal many files one at a time can derive a Key once with NewKey and call SealWithKey,
+// and open sealed files with a KeyCache, which is what the command line tool does.
+package aegis
+
+import (
+	"aegis/internal/crypto"
+	"io"
+)
+
+// Reserved file names written by the command line tool next to sealed files. Directory walks
+// leave them alone.
+const (
+	SealedExt            = ".aegis"          // Extension of every sealed file (unless names are hidden).
+	NamesManifestFile    = ".aegis-names"    // Encrypted per-directory map of hidden names to original names.
+	ManifestFile         = "aegis.manifest"  // Encrypted record of a sealed tree written by seal --manifest.
+	ChecksumManifestFile = "aegis.checksums" // Signed checksums of a sealed tree written by seal --checksum-manifest.
+)
+
+// Key is a key derived from a password, with the random salt and scrypt parameters recorded
+// in every file sealed with it.
+type Key = crypto.Key
+
+// KeyCache derives the keys needed to open sealed files from one password, running scrypt
+// once per salt. It is safe for concurrent use.
+type KeyCache = crypto.KeyCache
+
+// KDFParams are the scrypt cost parameters a Key is derived with.
+type KDFParams = crypto.KDFParams
+
+// Metadata is the information sealed alongside a file's content.
+type Metadata = crypto.Metadata
+
+// Payload is an opened sealed file: its metadata and a reader of the authenticated plaintext.
+type Payload = crypto.Payload
+
+// DefaultKDFParams are the scrypt parameters used when none are given.
+var DefaultKDFParams = crypto.DefaultKDFParams
+
+// ErrDecryptFailed is returned (wrapped) when a sealed file does not authenticate: a wrong
+// password, a corrupted file, or a file renamed after it was sealed.
+var ErrDecryptFailed = crypto.ErrDecryptFailed
+
+// ErrWrongPassword and ErrCorrupted refine ErrDecryptFailed for files sealed in format v9 or
+// later, whose headers record a key check value: the first means the password (or key file) is
+// wrong, the second that it is right but the file was damaged or renamed. Both wrap
+// ErrDecryptFailed.
+var (
+	ErrWrongPassword = crypto.ErrWrongPassword
+	ErrCorrupted     = crypto.ErrCorrupted
+)
+
+// NewKey derives a key from password with a fresh random salt.
+func NewKey(password string, kdf KDFParams) (*Key, error) {
+	return crypto.NewKey(password, kdf)
+}
+
+// ErrKeyfileRequired is returned (wrapped) when a file sealed with a key file is opened with
+// a KeyCache made without one.
+var ErrKeyfileRequired = crypto.ErrKeyfileRequired
+
+// NewKeyCache returns an empty KeyCache for password.
+func NewKeyCache(password string) *KeyCache {
+	return crypto.NewKeyCache(password)
+}
+
+// NewKeyfileKey derives a key from password and the content of a key file; files sealed with
+// it need both to open.
+func NewKeyfileKey(password string, keyfile []byte, kdf KDFParams) (*Key, error) {
+	return crypto.NewKeyfileKey(password, keyfile, kdf)
+}
+
+// NewKeyfileKeyCache returns an empty KeyCache for password and the content of a key file. It
+// also opens files sealed with the password alone.
+func NewKeyfileKeyCache(password string, keyfile []byte) *KeyCache {
+	return crypto.NewKeyfileKeyCache(password, keyfile)
+}
+
+// Open reads the header and metadata of a sealed file from r; name is the sealed file's base
+// name, which is bound into the file when it is sealed. The content is authenticated chunk by
+// chunk as Payload.Content is read.
+func Open(r io.Reader, name string, keys *KeyCache) (*Payload, error) {
+	return crypto.Open(r, name, keys)
+}
+
+// FileError records a file that could not be sealed or unsealed.
+type FileError struct {
+	Path string
+	Err  error
+}
+
+func (e *FileError) Error() string {
+	return e.Path + ": " + e.Err.Error()
+}
+
+func (e *FileError) Unwrap() error {
+	return e.Err
+}
+
+// Result summarizes a SealDir or UnsealDir run. Files that fail are recorded in Errors and the
+// run continues with the next file.
+type Result struct {
+	Files   []string     // Paths written: sealed files for SealDir, restored files for UnsealDir.
+	Skipped int          // Entries left alone: symlinks, reserved names, files not to be sealed or unsealed.
+	Bytes   int64        // Plaintext bytes sealed or restored.
+	Errors  []*FileError // Files that failed, in walk order.
+}
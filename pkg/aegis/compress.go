@@ -0,0 +1,74 @@
+package aegis
+
+import (
+	"bytes"
+	"compress/flate"
+	"io"
+	"strings"
+)
+
+// incompressibleExts lists extensions of formats that are already compressed; compression
+// leaves them as they are instead of spending time deflating them for no gain.
+var incompressibleExts = map[string]bool{
+	".gz": true, ".tgz": true, ".bz2": true, ".xz": true, ".zst": true, ".lz4": true,
+	".zip": true, ".7z": true, ".rar": true, ".jar": true,
+	".docx": true, ".xlsx": true, ".pptx": true, ".odt": true, ".epub": true,
+	".jpg": true, ".jpeg": true, ".png": true, ".gif": true, ".webp": true, ".heic": true,
+	".mp3": true, ".aac": true, ".ogg": true, ".flac": true, ".opus": true,
+	".mp4": true, ".mkv": true, ".mov": true, ".webm": true, ".avi": true,
+	".pdf": true,
+}
+
+// shouldCompress reports whether a file with this extension is worth compressing.
+func shouldCompress(ext string) bool {
+	return !incompressibleExts[strings.ToLower(ext)]
+}
+
+// deflateContent compresses size bytes from r in memory. It reports ok=false when the
+// compressed form is not smaller, in which case the caller should store the content as is.
+func deflateContent(r io.Reader, size int64) (compressed []byte, ok bool, err error) {
+	var buf bytes.Buffer
+	zw, err := flate.NewWriter(&buf, flate.DefaultCompression)
+	if err != nil {
+		return nil, false, err
+	}
+	if _, err := io.CopyN(zw, r, size); err != nil {
+		return nil, false, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, false, err
+	}
+	if int64(buf.Len()) >= size {
+		return nil, false, nil
+	}
+	return buf.Bytes(), true, nil
+}
+
+// deflateIfSmaller returns a reader of the deflated form of size bytes of content when that is
+// smaller, and otherwise a reader of the content as is. Seekable content (a file) is rewound
+// rather than buffered, so only the deflated form is held in memory.
+func deflateIfSmaller(content io.Reader, size int64) (io.Reader, int64, bool, error) {
+	rs, ok := content.(io.ReadSeeker)
+	if !ok {
+		data, err := io.ReadAll(io.LimitReader(content, size))
+		if err != nil {
+			return nil, 0, false, err
+		}
+		rs = bytes.NewReader(data)
+	}
+	start, err := rs.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, 0, false, err
+	}
+	deflated, ok, err := deflateContent(rs, size)
+	if err != nil {
+		return nil, 0, false, err
+	}
+	if ok {
+		return bytes.NewReader(deflated), int64(len(deflated)), true, nil
+	}
+	if _, err := rs.Seek(start, io.SeekStart); err != nil { // Rewinds to store the content uncompressed.
+		return nil, 0, false, err
+	}
+	return rs, size, false, nil
+}
@@ -0,0 +1,218 @@
+package aegis
+
+import (
+	"aegis/internal/atomicfile"
+	"aegis/internal/crypto"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// SealOptions configures SealFile and SealDir. The zero value seals with the default scrypt
+// parameters, without compression, and removes each original once it is sealed.
+type SealOptions struct {
+	KDF      KDFParams // scrypt parameters; the zero value means DefaultKDFParams.
+	Compress bool      // Deflate content before encryption when that makes it smaller.
+	Keep     bool      // Keep the originals instead of removing them after sealing.
+
+	// Skip, when set, is called by SealDir for every file and directory below the root, with
+	// its path relative to the root. Returning true leaves the file unsealed, or the directory
+	// unvisited.
+	Skip func(rel string, info fs.FileInfo) bool
+}
+
+func (o SealOptions) kdf() KDFParams {
+	if o.KDF == (KDFParams{}) {
+		return DefaultKDFParams
+	}
+	return o.KDF
+}
+
+// SealFile seals the file at path into SealedPath(path) and, unless opts.Keep is set, removes
+// the original. It returns the path of the sealed file. Nothing is done if ctx is already done.
+func SealFile(ctx context.Context, path, password string, opts SealOptions) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	key, err := NewKey(password, opts.kdf())
+	if err != nil {
+		return "", err
+	}
+	return sealOne(path, key, NewKeyCache(password), opts)
+}
+
+// SealDir seals every regular file under dir in place, with one key derived for the whole run.
+// Symlinks, files that are already sealed and the reserved names are skipped. Per-file
+// failures are recorded in the result; the error is only non-nil when the run could not
+// start, the walk itself failed, or ctx was canceled.
+//
+// ctx is checked before each file, so a canceled run stops between files: every file is then
+// either sealed (and its original removed) or untouched, and the result describes the files
+// sealed so far.
+func SealDir(ctx context.Context, dir, password string, opts SealOptions) (*Result, error) {
+	key, err := NewKey(password, opts.kdf())
+	if err != nil {
+		return nil, err
+	}
+	keys := NewKeyCache(password)
+
+	res := &Result{}
+	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if path == dir {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if opts.Skip != nil {
+			rel, err := filepath.Rel(dir, path)
+			if err != nil {
+				return err
+			}
+			if opts.Skip(rel, info) {
+				res.Skipped++
+				if d.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+		}
+		if d.IsDir() {
+			return nil
+		}
+		if !info.Mode().IsRegular() || reserved(d.Name()) || strings.HasSuffix(d.Name(), SealedExt) {
+			res.Skipped++
+			return nil
+		}
+
+		out, err := sealOne(path, key, keys, opts)
+		if err != nil {
+			res.Errors = append(res.Errors, &FileError{Path: path, Err: err})
+			return nil
+		}
+		res.Files = append(res.Files, out)
+		res.Bytes += info.Size()
+		return nil
+	})
+	return res, err
+}
+
+// sealOne seals path next to itself with key, refusing to replace the sealed form of a
+// different file, and removes the original unless opts.Keep is set.
+func sealOne(path string, key *Key, keys *KeyCache, opts SealOptions) (string, error) {
+	out := SealedPath(path)
+	if err := CheckSealTarget(path, out, keys); err != nil {
+		return "", err
+	}
+	if err := SealWithKey(path, out, key, opts.Compress); err != nil {
+		return "", err // The original is left untouched on failure.
+	}
+	if !opts.Keep {
+		if err := os.Remove(path); err != nil {
+			return "", fmt.Errorf("sealed to '%s' but could not remove the original: %v", out, err)
+		}
+	}
+	return out, nil
+}
+
+// reserved reports whether name is bookkeeping written by aegis itself, which is never sealed.
+func reserved(name string) bool {
+	return name == NamesManifestFile || name == ManifestFile || name == ChecksumManifestFile || atomicfile.IsTemp(name)
+}
+
+// SealWithKey seals the file at path into out with key, recording its permissions, modification
+// time and name. The content is streamed, never fully loaded into memory (except its deflated
+// form when compress is set). The original is never modified.
+func SealWithKey(path, out string, key *Key, compress bool) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	info, err := src.Stat() // Describes the file itself, also when path is a symlink to it.
+	if err != nil {
+		return err
+	}
+
+	name := filepath.Base(path)
+	_, ext := SplitExt(name)
+	meta := Metadata{Mode: info.Mode(), ModTime: info.ModTime(), Ext: ext, Name: name}
+	return WriteSealed(out, meta, src, info.Size(), compress, key)
+}
+
+// WriteSealed seals meta and size bytes of content with key into out. With compress, content
+// whose extension is worth compressing is deflated first and stored compressed when that is
+// smaller. The file is written atomically, so a failure or interruption never leaves a
+// truncated sealed file behind.
+func WriteSealed(out string, meta Metadata, content io.Reader, size int64, compress bool, key *Key) error {
+	return atomicfile.Write(out, 0600, func(w io.Writer) error {
+		return Seal(w, filepath.Base(out), meta, content, size, compress, key) // Binds the final file name.
+	})
+}
+
+// Seal writes the sealed form of meta and size bytes of content to w, bound to sealedName: the
+// file must be opened under that base name. compress is as for WriteSealed.
+func Seal(w io.Writer, sealedName string, meta Metadata, content io.Reader, size int64, compress bool, key *Key) error {
+	compressed := false
+	if compress && shouldCompress(meta.Ext) {
+		var err error
+		if content, size, compressed, err = deflateIfSmaller(content, size); err != nil {
+			return fmt.Errorf("compression failed: %v", err)
+		}
+	}
+	return crypto.Seal(w, sealedName, meta, content, size, compressed, key)
+}
+
+// CheckSealTarget reports an error when sealing path into out would replace the sealed form of
+// a different file, such as notes.md onto the notes.aegis of notes.txt. An existing out that
+// opens with keys and records the same extension is an earlier seal of this file and may be replaced.
+func CheckSealTarget(path, out string, keys *KeyCache) error {
+	f, err := os.Open(out)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	payload, err := crypto.Open(f, filepath.Base(out), keys) // Decrypts only the header and metadata.
+	_, ext := SplitExt(filepath.Base(path))
+	switch {
+	case err != nil:
+		return fmt.Errorf("'%s' already exists and does not open with this password; not overwriting it", out)
+	case !payload.HasExt || payload.Ext != ext:
+		return fmt.Errorf("'%s' already holds another file with the same name (extension %q); not overwriting it", out, payload.Ext)
+	}
+	return nil
+}
+
+// SealedPath returns the in-place output path for path: the extension is replaced by .aegis.
+func SealedPath(path string) string {
+	stem, _ := SplitExt(filepath.Base(path))
+	return filepath.Join(filepath.Dir(path), stem+SealedExt)
+}
+
+// SplitExt splits a file name into the stem the sealed file is named after and the extension
+// stored in its metadata; unsealing joins them back together. Unlike filepath.Ext, a leading dot
+// does not start an extension, so ".gitignore" is sealed as ".gitignore.aegis" rather than
+// ".aegis", where every dotfile in the directory would collide. "archive.tar.gz" splits into
+// "archive.tar" and ".gz", "file." into "file" and ".", and "README" has no extension.
+func SplitExt(name string) (stem, ext string) {
+	ext = filepath.Ext(name)
+	if ext == name { // A dotfile with no further dot.
+		return name, ""
+	}
+	return strings.TrimSuffix(name, ext), ext
+}
@@ -0,0 +1,62 @@
+package aegis
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSealedNameRoundTrip(t *testing.T) {
+	tests := []struct {
+		name      string
+		stem, ext string
+		sealed    string
+	}{
+		{"README", "README", "", "README.aegis"},
+		{".gitignore", ".gitignore", "", ".gitignore.aegis"},
+		{".env.local", ".env", ".local", ".env.aegis"},
+		{"archive.tar.gz", "archive.tar", ".gz", "archive.tar.aegis"},
+		{"file.", "file", ".", "file.aegis"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stem, ext := SplitExt(tt.name)
+			if stem != tt.stem || ext != tt.ext {
+				t.Errorf("SplitExt(%q) = %q, %q; want %q, %q", tt.name, stem, ext, tt.stem, tt.ext)
+			}
+			path := filepath.Join("dir", tt.name)
+			if got, want := SealedPath(path), filepath.Join("dir", tt.sealed); got != want {
+				t.Errorf("SealedPath(%q) = %q, want %q", path, got, want)
+			}
+			// Files sealed before v8 record only the extension; v8+ files also record the full name.
+			if got := RestoredName(tt.sealed, &Payload{Metadata: Metadata{Ext: ext}}); got != tt.name {
+				t.Errorf("RestoredName(%q) with extension %q = %q, want %q", tt.sealed, ext, got, tt.name)
+			}
+			if got := RestoredName(tt.sealed, &Payload{Metadata: Metadata{Ext: ext, Name: tt.name}}); got != tt.name {
+				t.Errorf("RestoredName(%q) with name %q = %q, want %q", tt.sealed, tt.name, got, tt.name)
+			}
+
+			dir := t.TempDir()
+			orig := filepath.Join(dir, tt.name)
+			if err := os.WriteFile(orig, []byte(tt.name), 0600); err != nil {
+				t.Fatal(err)
+			}
+			ctx := context.Background()
+			sealed, err := SealFile(ctx, orig, "pw", SealOptions{KDF: testKDF})
+			if err != nil {
+				t.Fatalf("SealFile: %v", err)
+			}
+			if filepath.Base(sealed) != tt.sealed {
+				t.Errorf("SealFile(%q) wrote %q, want %q", tt.name, filepath.Base(sealed), tt.sealed)
+			}
+			restored, err := UnsealFile(ctx, sealed, "pw", UnsealOptions{})
+			if err != nil {
+				t.Fatalf("UnsealFile: %v", err)
+			}
+			if restored != orig {
+				t.Errorf("UnsealFile restored %q, want %q", restored, orig)
+			}
+		})
+	}
+}
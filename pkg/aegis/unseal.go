@@ -0,0 +1,151 @@
+package aegis
+
+import (
+	"aegis/internal/atomicfile"
+	"aegis/internal/crypto"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// UnsealOptions configures UnsealFile and UnsealDir. The zero value keeps the sealed files and
+// never replaces an existing file.
+type UnsealOptions struct {
+	RemoveSealed bool // Remove each sealed file once its plaintext has been restored and authenticated.
+	Overwrite    bool // Replace existing files at the restored paths instead of failing with ErrExists.
+}
+
+// ErrExists is returned (wrapped) when a file would be restored over an existing file without
+// UnsealOptions.Overwrite.
+var ErrExists = errors.New("a file already exists at the restored path")
+
+// UnsealFile restores the sealed file at path next to itself under its original name and
+// returns the restored path. Nothing is written unless the whole file authenticates, or if ctx
+// is already done.
+func UnsealFile(ctx context.Context, path, password string, opts UnsealOptions) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	out, _, err := unsealOne(path, NewKeyCache(password), opts)
+	return out, err
+}
+
+// UnsealDir restores every .aegis file under dir next to itself. Keys are derived once per salt,
+// so a tree sealed in one run costs one scrypt derivation. Per-file failures, including a wrong
+// password (ErrDecryptFailed), are recorded in the result; the error is only non-nil when the
+// walk itself failed or ctx was canceled, which is checked before each file. Names hidden by
+// the command line tool's seal --hide-names are restored from the sealed files themselves,
+// which record them since format v8.
+func UnsealDir(ctx context.Context, dir, password string, opts UnsealOptions) (*Result, error) {
+	keys := NewKeyCache(password)
+	res := &Result{}
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		if !d.Type().IsRegular() || !strings.HasSuffix(d.Name(), SealedExt) {
+			res.Skipped++
+			return nil
+		}
+
+		out, n, err := unsealOne(path, keys, opts)
+		if err != nil {
+			res.Errors = append(res.Errors, &FileError{Path: path, Err: err})
+			return nil
+		}
+		res.Files = append(res.Files, out)
+		res.Bytes += n
+		return nil
+	})
+	return res, err
+}
+
+// unsealOne restores path next to itself and returns the restored path and its size.
+func unsealOne(path string, keys *KeyCache, opts UnsealOptions) (string, int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", 0, err
+	}
+	defer f.Close()
+
+	payload, err := crypto.Open(f, filepath.Base(path), keys)
+	if err != nil {
+		return "", 0, err
+	}
+	name := RestoredName(filepath.Base(path), payload)
+	if name == "" {
+		return "", 0, errors.New("no file name to restore")
+	}
+	out := filepath.Join(filepath.Dir(path), name)
+	if !opts.Overwrite {
+		if _, err := os.Lstat(out); err == nil {
+			return "", 0, fmt.Errorf("'%s': %w", out, ErrExists)
+		}
+	}
+
+	content := &countingReader{r: payload.Content}
+	if err := WritePlaintext(out, content); err != nil {
+		return "", 0, err
+	}
+	// Attributes are restored on a best-effort basis, as by the command line tool, which only
+	// warns when they cannot be.
+	os.Chmod(out, payload.Mode) // Umask does not apply to Chmod.
+	if !payload.ModTime.IsZero() {
+		os.Chtimes(out, payload.ModTime, payload.ModTime)
+	}
+
+	if opts.RemoveSealed {
+		if err := os.Remove(path); err != nil {
+			return "", 0, fmt.Errorf("restored to '%s' but could not remove the sealed file: %v", out, err)
+		}
+	}
+	return out, content.n, nil
+}
+
+// RestoredName returns the name the sealed file sealedName restores to: the full original name
+// recorded by format v8 and later, or else the sealed file's stem joined with the recorded
+// extension. It is empty when there is nothing to name the file after.
+func RestoredName(sealedName string, p *Payload) string {
+	if IsBaseName(p.Name) {
+		return p.Name
+	}
+	return strings.TrimSuffix(sealedName, SealedExt) + p.Ext
+}
+
+// IsBaseName reports whether name can be used as a file name inside the output directory: not
+// empty, "." or "..", and without path separators, so a crafted name cannot escape the directory.
+func IsBaseName(name string) bool {
+	return name != "" && name != "." && filepath.Base(name) == name && filepath.IsLocal(name)
+}
+
+// WritePlaintext atomically streams r into path; nothing appears at path unless the whole
+// file decrypted and authenticated.
+func WritePlaintext(path string, r io.Reader) error {
+	return atomicfile.Write(path, 0600, func(w io.Writer) error {
+		_, err := io.Copy(w, r)
+		return err
+	})
+}
+
+// countingReader counts the bytes read through it.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,55 @@
+package aegis
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// testKDF keeps scrypt cheap; the parameters are recorded in each file, so unsealing needs no setup.
+var testKDF = KDFParams{N: 1 << 10, R: 8, P: 1, KeyLen: 32}
+
+func TestUnsealDirDottedDirectory(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "a.b")
+	if err := os.Mkdir(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	orig := filepath.Join(dir, "c")
+	if err := os.WriteFile(orig, []byte("secret"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	if _, err := SealDir(ctx, root, "pw", SealOptions{KDF: testKDF}); err != nil {
+		t.Fatalf("SealDir: %v", err)
+	}
+	sealed := filepath.Join(dir, "c"+SealedExt)
+	if _, err := os.Stat(sealed); err != nil {
+		t.Fatalf("sealed file: %v", err)
+	}
+
+	res, err := UnsealDir(ctx, root, "pw", UnsealOptions{RemoveSealed: true})
+	if err != nil {
+		t.Fatalf("UnsealDir: %v", err)
+	}
+	if len(res.Errors) != 0 {
+		t.Fatalf("UnsealDir errors: %v", res.Errors)
+	}
+	if len(res.Files) != 1 || res.Files[0] != orig {
+		t.Fatalf("restored %q, want [%q]", res.Files, orig)
+	}
+	got, err := os.ReadFile(orig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "secret" {
+		t.Errorf("content = %q, want %q", got, "secret")
+	}
+	for _, wrong := range []string{filepath.Join(dir, "c."), filepath.Join(root, "a")} {
+		if _, err := os.Lstat(wrong); err == nil {
+			t.Errorf("unexpected file %s", wrong)
+		}
+	}
+}